@@ -0,0 +1,135 @@
+// Package amf parses Additive Manufacturing File Format (AMF) files
+// into solids.
+package amf
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"sigint.ca/slice/stl"
+	"sigint.ca/slice/vector"
+)
+
+// unitScale converts AMF units into millimeters.
+var unitScale = map[string]float64{
+	"":           1, // millimeter is the default
+	"micron":     0.001,
+	"millimeter": 1,
+	"meter":      1000,
+	"inch":       25.4,
+	"feet":       304.8,
+}
+
+type xmlAMF struct {
+	XMLName xml.Name    `xml:"amf"`
+	Unit    string      `xml:"unit,attr"`
+	Objects []xmlObject `xml:"object"`
+}
+
+type xmlObject struct {
+	ID       string        `xml:"id,attr"`
+	Metadata []xmlMetadata `xml:"metadata"`
+	Vertices []xmlVertex   `xml:"mesh>vertices>vertex"`
+	Volumes  []xmlVolume   `xml:"mesh>volume"`
+}
+
+type xmlMetadata struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type xmlVertex struct {
+	X float64 `xml:"coordinates>x"`
+	Y float64 `xml:"coordinates>y"`
+	Z float64 `xml:"coordinates>z"`
+}
+
+type xmlVolume struct {
+	Triangles []xmlTriangle `xml:"triangle"`
+}
+
+type xmlTriangle struct {
+	V1 int `xml:"v1"`
+	V2 int `xml:"v2"`
+	V3 int `xml:"v3"`
+}
+
+// Parse parses an AMF file from r, which may be plain XML or a zip archive
+// containing it, and returns a solid for each object, with the volumes of
+// each object merged and coordinates converted into millimeters. Solids are
+// named after their objects' "name" metadata, or otherwise their IDs.
+//
+// Only geometry is read; materials, colors, curved triangle edges and
+// constellations are ignored.
+func Parse(r io.Reader) ([]*stl.Solid, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(4); string(magic) == "PK\x03\x04" {
+		rc, err := unzip(br)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding AMF: %v", err)
+		}
+		defer rc.Close()
+		r = rc
+	} else {
+		r = br
+	}
+
+	var doc xmlAMF
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("error decoding AMF: %v", err)
+	}
+	scale, ok := unitScale[doc.Unit]
+	if !ok {
+		return nil, fmt.Errorf("error decoding AMF: unknown unit %q", doc.Unit)
+	}
+
+	solids := make([]*stl.Solid, 0, len(doc.Objects))
+	for _, o := range doc.Objects {
+		facets := make([]stl.Facet, 0)
+		for _, vol := range o.Volumes {
+			for i, t := range vol.Triangles {
+				var f stl.Facet
+				for j, vi := range [3]int{t.V1, t.V2, t.V3} {
+					if vi < 0 || vi >= len(o.Vertices) {
+						return nil, fmt.Errorf("error decoding AMF: object %s: triangle %d: bad vertex index %d", o.ID, i, vi)
+					}
+					v := o.Vertices[vi]
+					f.Vertices[j] = vector.V3{X: v.X, Y: v.Y, Z: v.Z}.Mul(scale)
+				}
+				facets = append(facets, f)
+			}
+		}
+		s := stl.NewSolid(facets)
+		s.Name = o.ID
+		for _, m := range o.Metadata {
+			if m.Type == "name" {
+				s.Name = m.Value
+			}
+		}
+		s.ReconcileNormals(stl.TrustWinding)
+		solids = append(solids, s)
+	}
+	return solids, nil
+}
+
+// unzip returns the first file of a zip archive read from r.
+func unzip(r io.Reader) (io.ReadCloser, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range zr.File {
+		if !f.FileInfo().IsDir() {
+			return f.Open()
+		}
+	}
+	return nil, fmt.Errorf("empty zip archive")
+}
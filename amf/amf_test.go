@@ -0,0 +1,113 @@
+package amf
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// cube returns an AMF object for a unit cube split into two volumes.
+func cube(id, name string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<object id=%q>\n", id)
+	if name != "" {
+		fmt.Fprintf(&b, "<metadata type=\"name\">%s</metadata>\n", name)
+	}
+	b.WriteString("<mesh><vertices>\n")
+	for _, v := range [][3]int{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 1, 0}, {0, 0, 1}, {1, 0, 1}, {1, 1, 1}, {0, 1, 1}} {
+		fmt.Fprintf(&b, "<vertex><coordinates><x>%d</x><y>%d</y><z>%d</z></coordinates></vertex>\n", v[0], v[1], v[2])
+	}
+	b.WriteString("</vertices>\n")
+	tris := [][3]int{
+		{0, 2, 1}, {0, 3, 2}, {4, 5, 6}, {4, 6, 7}, {0, 1, 5}, {0, 5, 4},
+		{1, 2, 6}, {1, 6, 5}, {2, 3, 7}, {2, 7, 6}, {3, 0, 4}, {3, 4, 7},
+	}
+	for _, vol := range [][][3]int{tris[:6], tris[6:]} {
+		b.WriteString("<volume>\n")
+		for _, t := range vol {
+			fmt.Fprintf(&b, "<triangle><v1>%d</v1><v2>%d</v2><v3>%d</v3></triangle>\n", t[0], t[1], t[2])
+		}
+		b.WriteString("</volume>\n")
+	}
+	b.WriteString("</mesh></object>\n")
+	return b.String()
+}
+
+var doc = `<?xml version="1.0" encoding="UTF-8"?>
+<amf unit="inch" version="1.1">
+` + cube("0", "") + cube("1", "second") + `
+<constellation id="2"><instance objectid="0"><deltax>5</deltax></instance></constellation>
+</amf>`
+
+func zipped(t *testing.T, name, data string) string {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name, data string
+	}{
+		{"plain", doc},
+		{"zipped", zipped(t, "cubes.amf", doc)},
+	}
+	for _, test := range tests {
+		solids, err := Parse(strings.NewReader(test.data))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if len(solids) != 2 {
+			t.Errorf("%s: got %d solids, want 2", test.name, len(solids))
+			continue
+		}
+		for i, name := range []string{"0", "second"} {
+			s := solids[i]
+			if s.Name != name {
+				t.Errorf("%s: solid %d: got name %q, want %q", test.name, i, s.Name, name)
+			}
+			if len(s.Facets) != 12 {
+				t.Errorf("%s: solid %d: got %d facets, want 12", test.name, i, len(s.Facets))
+			}
+			if _, max := s.Bounds(); max.X != 25.4 {
+				t.Errorf("%s: solid %d: got max %v, want 25.4 (one inch)", test.name, i, max)
+			}
+			if v, want := s.Volume(), math.Pow(25.4, 3); math.Abs(v-want) > 1e-6 {
+				t.Errorf("%s: solid %d: got volume %v, want %v", test.name, i, v, want)
+			}
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name, data, err string
+	}{
+		{"unit", `<amf unit="furlong"></amf>`, `unknown unit "furlong"`},
+		{"index", `<amf><object id="7"><mesh><vertices></vertices>
+			<volume><triangle><v1>0</v1><v2>1</v2><v3>2</v3></triangle></volume></mesh></object></amf>`,
+			"object 7: triangle 0: bad vertex index 0"},
+		{"xml", `<amf><object>`, "unexpected EOF"},
+		{"zip", "PK\x03\x04garbage", "zip"},
+	}
+	for _, test := range tests {
+		_, err := Parse(strings.NewReader(test.data))
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: got error %v, want %q", test.name, err, test.err)
+		}
+	}
+}
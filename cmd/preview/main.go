@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"sigint.ca/slice"
+	"sigint.ca/slice/load"
 	"sigint.ca/slice/stl"
 
 	"golang.org/x/exp/shiny/driver"
@@ -43,16 +44,25 @@ func main() {
 		log.Fatal(err)
 	}
 
-	log.Print("parsing STL...")
+	log.Print("parsing model...")
 	t := time.Now()
-	stl, err := stl.Parse(f)
+	solids, format, err := load.Read(f)
 	if err != nil {
 		log.Fatal(err)
 	}
 	f.Close()
-	log.Printf("parsing took %v", time.Now().Sub(t))
+	log.Printf("parsing %v took %v", format, time.Now().Sub(t))
 
-	layers, err := sliceSTL(stl)
+	// slice all of the solids together, as they are placed
+	var facets []stl.Facet
+	for _, s := range solids {
+		facets = append(facets, s.Facets...)
+	}
+
+	solid := stl.NewSolid(facets)
+	solid.PlaceOnBed()
+
+	layers, err := sliceSTL(solid)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -173,10 +183,16 @@ func sliceSTL(stl *stl.Solid) ([]*slice.Layer, error) {
 		Infill:      &slice.Concentric{Spacing: 0.2},
 	}
 
-	layers, err := slice.Slice(stl, cfg)
+	layers, diags, err := slice.Slice(stl, cfg)
 	if err != nil {
 		return nil, err
 	}
+	for k, n := range diags.Count() {
+		log.Printf("%d problems: %v", n, k)
+	}
+	if err := diags.Err(); err != nil {
+		log.Print(err)
+	}
 
 	log.Printf("slicing took %v", time.Now().Sub(t))
 	return layers, nil
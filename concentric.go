@@ -10,7 +10,7 @@ func (in *Concentric) Fill(r *Region) {
 	lastRound := r.Exterior
 
 	for round := 0; round < 1; round++ {
-		dprintf("starting concentric infill round %d", round)
+		r.log.debugf("starting concentric infill round %d", round)
 		roundStart := len(r.Infill)
 
 		// shift everything inwards
@@ -28,13 +28,13 @@ func (in *Concentric) Fill(r *Region) {
 			// no new segments, we're done.
 			break
 		}
-		dprintf("added %d segments in round %d", roundEnd-roundStart, round)
+		r.log.debugf("added %d segments in round %d", roundEnd-roundStart, round)
 
 		lastRound = r.Infill[roundStart:roundEnd]
 
 		// join and trim newly shifted segments.
 		// there will be some overlapping, which we will eliminate in the next phase.
-		in.connect(lastRound)
+		in.connect(r.log, lastRound)
 
 		// eliminate overlapping segments
 		in.trim(lastRound)
@@ -44,7 +44,7 @@ func (in *Concentric) Fill(r *Region) {
 	}
 }
 
-func (in *Concentric) connect(segments []*Segment) {
+func (in *Concentric) connect(lg logger, segments []*Segment) {
 	for i := 0; i < len(segments); i++ {
 		a := segments[i]
 		j := (i + 1) % len(segments)
@@ -53,7 +53,12 @@ func (in *Concentric) connect(segments []*Segment) {
 		bLine := b.getLine()
 		intersection, err := aLine.intersect(bLine)
 		if err == errNoIntersections {
-			wprintf("can't connect: no intersections!")
+			lg.report(Diagnostic{
+				Kind:     InfillFailed,
+				Location: a.To,
+				Facet:    -1,
+				Msg:      "can't connect: no intersections",
+			})
 		} else {
 			a.To = intersection
 			b.From = intersection
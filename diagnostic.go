@@ -0,0 +1,136 @@
+package slice
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// A Kind identifies the type of problem described by a Diagnostic.
+type Kind int
+
+const (
+	EmptyLayer      Kind = iota + 1 // no facets intersect the layer
+	OpenLoop                        // a perimeter could not be closed
+	OrphanHole                      // an interior perimeter lies outside every exterior
+	DegenerateFacet                 // a facet has (nearly) zero area
+	CoincidentFacet                 // a facet lies in the slice plane and was skipped
+	InfillFailed                    // infill segments could not be connected
+)
+
+var kindNames = map[Kind]string{
+	EmptyLayer:      "empty layer",
+	OpenLoop:        "open loop",
+	OrphanHole:      "orphan hole",
+	DegenerateFacet: "degenerate facet",
+	CoincidentFacet: "coincident facet",
+	InfillFailed:    "infill failed",
+}
+
+func (k Kind) String() string {
+	if s, ok := kindNames[k]; ok {
+		return s
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// Broken reports whether problems of kind k indicate a broken model, as
+// opposed to something which is expected to happen with valid input.
+func (k Kind) Broken() bool {
+	switch k {
+	case OpenLoop, OrphanHole, DegenerateFacet:
+		return true
+	}
+	return false
+}
+
+func (k Kind) level() slog.Level {
+	switch {
+	case k == CoincidentFacet:
+		return slog.LevelDebug
+	case k.Broken():
+		return slog.LevelError
+	}
+	return slog.LevelWarn
+}
+
+// A Diagnostic describes a non-fatal problem encountered while slicing.
+type Diagnostic struct {
+	Kind     Kind
+	Layer    int     // index of the layer in which the problem occurred
+	Z        float64 // height of the slice plane
+	Stage    string  // slicing stage which reported the problem, e.g. "perimeters"
+	Location Vertex2 // approximate position of the problem within the layer
+	Facet    int     // index of the offending facet in the solid, or -1
+	Msg      string
+}
+
+func (d Diagnostic) String() string {
+	s := fmt.Sprintf("layer %d (z=%.3f): %v at %v", d.Layer, d.Z, d.Kind, d.Location)
+	if d.Facet >= 0 {
+		s += fmt.Sprintf(" (facet %d)", d.Facet)
+	}
+	if d.Msg != d.Kind.String() {
+		s += ": " + d.Msg
+	}
+	return s
+}
+
+// Diagnostics is a list of problems collected during a slicing job,
+// ordered by layer.
+type Diagnostics []Diagnostic
+
+// Layer returns the diagnostics for layer n.
+func (ds Diagnostics) Layer(n int) Diagnostics {
+	var out Diagnostics
+	for _, d := range ds {
+		if d.Layer == n {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// Filter returns the diagnostics of the given kinds.
+func (ds Diagnostics) Filter(kinds ...Kind) Diagnostics {
+	var out Diagnostics
+	for _, d := range ds {
+		for _, k := range kinds {
+			if d.Kind == k {
+				out = append(out, d)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// Count returns the number of diagnostics of each kind.
+func (ds Diagnostics) Count() map[Kind]int {
+	m := make(map[Kind]int)
+	for _, d := range ds {
+		m[d.Kind]++
+	}
+	return m
+}
+
+// Err returns an error summarizing any diagnostics which indicate a
+// broken model, or nil if there are none.
+func (ds Diagnostics) Err() error {
+	var n int
+	var first Diagnostic
+	for _, d := range ds {
+		if d.Kind.Broken() {
+			if n == 0 {
+				first = d
+			}
+			n++
+		}
+	}
+	switch n {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("broken model: %v", first)
+	}
+	return fmt.Errorf("broken model: %v (and %d more problems)", first, n-1)
+}
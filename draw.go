@@ -17,6 +17,7 @@ var (
 	perimeterColor = color.Black
 	infillColor    = color.RGBA{R: 0xFF, A: 0xFF}
 	normalColor    = color.RGBA{G: 0xFF, A: 0xFF}
+	problemColor   = color.RGBA{R: 0xFF, G: 0x80, A: 0xFF}
 )
 
 func (l *Layer) Draw(dst draw.Image) {
@@ -55,6 +56,14 @@ func (l *Layer) Draw(dst draw.Image) {
 			drawSegment(dst, s, min2, infillColor, scaleFactor)
 		}
 	}
+
+	// highlight problems
+	for _, d := range l.diags {
+		if d.Kind.Broken() || d.Kind == InfillFailed {
+			px := v2pixel(d.Location, scaleFactor).Sub(v2pixel(min2, scaleFactor))
+			primitive.Circle(dst, problemColor, px, 6)
+		}
+	}
 }
 
 func drawSegment(dst draw.Image, s *Segment, min Vertex2, c color.Color, scaleFactor float64) {
@@ -25,7 +25,7 @@ func BenchmarkDraw(b *testing.B) {
 		LayerHeight: 1.0,
 		LineWidth:   1.0,
 	}
-	layers, err := Slice(stl, cfg)
+	layers, _, err := Slice(stl, cfg)
 	if err != nil {
 		b.Fatal(err)
 	}
@@ -0,0 +1,89 @@
+package slice
+
+import "math"
+
+// An Estimate gives the amount of material needed to print a sliced solid.
+type Estimate struct {
+	// Volume is the volume of the layers' regions in mm³: the volume of
+	// the solid as the printer sees it, if it were printed solid. Without
+	// a real infill pattern to go on, this is the material estimate.
+	Volume float64
+
+	// Extruded is the volume of plastic extruded along the layers' paths
+	// in mm³, taking each layer's height and line width into account.
+	// Slice generates only the outer perimeter of each region (and infill
+	// only in DebugMode), so this is a perimeter-only figure: a lower
+	// bound on the material used, not an estimate of it.
+	Extruded float64
+
+	// Length is the total length of the extruded paths in mm.
+	Length float64
+}
+
+// EstimateMaterial estimates the material needed to print layers.
+func EstimateMaterial(layers []*Layer) Estimate {
+	var e Estimate
+	for _, l := range layers {
+		e.Volume += l.Area() * l.height
+		for _, r := range l.regions {
+			var length float64
+			for _, s := range r.Exterior {
+				length += s.Length()
+			}
+			for _, p := range r.Interiors {
+				for _, s := range p {
+					length += s.Length()
+				}
+			}
+			for _, s := range r.Infill {
+				length += s.Length()
+			}
+			e.Length += length
+			e.Extruded += length * l.width * l.height
+		}
+	}
+	return e
+}
+
+// Filament returns the length in mm of filament of the given
+// diameter needed to print e.Volume solid.
+func (e Estimate) Filament(diameter float64) float64 {
+	r := diameter / 2
+	return e.Volume / (math.Pi * r * r)
+}
+
+// Mass returns the mass in grams of e.Volume printed solid, for a
+// material with the given density in g/cm³ (about 1.24 for PLA).
+// Parts printed with sparse infill will weigh less.
+func (e Estimate) Mass(density float64) float64 {
+	return e.Volume / 1000 * density
+}
+
+// Area returns the area enclosed by r's exterior perimeter,
+// less the areas of its holes.
+func (r *Region) Area() float64 {
+	area := math.Abs(perimeterArea(r.Exterior))
+	for _, p := range r.Interiors {
+		area -= math.Abs(perimeterArea(p))
+	}
+	return area
+}
+
+// Area returns the total area of the regions of l.
+func (l *Layer) Area() float64 {
+	var area float64
+	for _, r := range l.regions {
+		area += r.Area()
+	}
+	return area
+}
+
+// perimeterArea returns the signed area enclosed by an ordered perimeter,
+// by the shoelace formula.
+func perimeterArea(p []*Segment) float64 {
+	var a float64
+	for _, s := range p {
+		a += s.From.X*s.To.Y - s.To.X*s.From.Y
+	}
+	return a / 2
+}
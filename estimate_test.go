@@ -0,0 +1,48 @@
+package slice
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEstimateMaterial(t *testing.T) {
+	for _, test := range []struct {
+		file string
+		size float64
+	}{
+		{"testdata/cube20_ascii.stl", 20},
+		{"testdata/cube40_binary.stl", 40},
+	} {
+		s := parseFile(t, test.file)
+		cfg := Config{
+			Logger:      discardLogger,
+			LayerHeight: 0.2,
+			LineWidth:   0.4,
+		}
+		layers, _, err := Slice(s, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		e := EstimateMaterial(layers)
+		// the sliced volume matches the solid's, give or take a layer
+		if v := s.Volume(); math.Abs(e.Volume-v) > test.size*test.size*cfg.LayerHeight {
+			t.Errorf("%s: got sliced volume %v, want about %v", test.file, e.Volume, v)
+		}
+		// a single perimeter around each layer, since Slice doesn't infill
+		length := 4 * test.size * float64(len(layers))
+		if math.Abs(e.Length-length) > 1e-6*length {
+			t.Errorf("%s: got length %v, want %v", test.file, e.Length, length)
+		}
+		if want := length * cfg.LineWidth * cfg.LayerHeight; math.Abs(e.Extruded-want) > 1e-6*want {
+			t.Errorf("%s: got extruded volume %v, want %v", test.file, e.Extruded, want)
+		}
+		if f, want := e.Filament(1.75), e.Volume/(math.Pi*0.875*0.875); math.Abs(f-want) > 1e-9 {
+			t.Errorf("%s: got filament length %v, want %v", test.file, f, want)
+		}
+		// a solid cube of PLA
+		if m, want := e.Mass(1.24), s.Volume()/1000*1.24; math.Abs(m-want) > 0.01*want {
+			t.Errorf("%s: got mass %v, want about %v", test.file, m, want)
+		}
+	}
+}
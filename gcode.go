@@ -3,37 +3,62 @@ package slice
 import (
 	"fmt"
 	"io"
+	"math"
 )
 
 type Encoder struct {
 	w io.Writer
+
+	// FilamentDiameter is the diameter of the filament in mm. It is used
+	// to convert the volume of each extruded line into E axis movement.
+	FilamentDiameter float64
+
+	started bool // whether the extrusion mode has been set
 }
 
 func NewEncoder(w io.Writer) *Encoder {
-	return &Encoder{w: w}
+	return &Encoder{w: w, FilamentDiameter: 1.75}
 }
 
-// EncodeLayer compiles a layer into gcode.
+// EncodeLayer compiles a layer into gcode. Extrusion amounts are relative,
+// and account for the layer's own height and line width; the first layer
+// encoded is preceded by M83 to put the extruder in relative mode. The
+// feed rate is set from the layer's speed, if any.
 func (e *Encoder) EncodeLayer(l *Layer) {
+	if !e.started {
+		fmt.Fprintln(e.w, "M83")
+		e.started = true
+	}
+	if l.speed != 0 {
+		fmt.Fprintf(e.w, "G1 Z%.5f F%.0f\n", l.z, l.speed*60)
+	} else {
+		fmt.Fprintf(e.w, "G1 Z%.5f\n", l.z)
+	}
 	for _, region := range l.Regions() {
 		//perimeters
 		s := region.Exterior[0]
 		fmt.Fprintf(e.w, "G1 X%.5f Y%.5f\n", s.From.X, s.From.Y)
 		for _, s := range region.Exterior {
-			fmt.Fprintf(e.w, "G1 X%.5f Y%.5f E%.5f\n", s.To.X, s.To.Y, 0.0)
+			fmt.Fprintf(e.w, "G1 X%.5f Y%.5f E%.5f\n", s.To.X, s.To.Y, e.extrusion(l, s))
 		}
 		for _, p := range region.Interiors {
 			s := p[0]
 			fmt.Fprintf(e.w, "G1 X%.5f Y%.5f\n", s.From.X, s.From.Y)
 			for _, s := range p {
-				fmt.Fprintf(e.w, "G1 X%.5f Y%.5f E%.5f\n", s.To.X, s.To.Y, 0.0)
+				fmt.Fprintf(e.w, "G1 X%.5f Y%.5f E%.5f\n", s.To.X, s.To.Y, e.extrusion(l, s))
 			}
 		}
 
 		//infill
 		// TODO: non-printing moves
 		for _, s := range region.Infill {
-			fmt.Fprintf(e.w, "G1 X%.5f Y%.5f E%.5f\n", s.To.X, s.To.Y, 0.0)
+			fmt.Fprintf(e.w, "G1 X%.5f Y%.5f E%.5f\n", s.To.X, s.To.Y, e.extrusion(l, s))
 		}
 	}
 }
+
+// extrusion returns the length of filament needed to print s in layer l.
+func (e *Encoder) extrusion(l *Layer, s *Segment) float64 {
+	r := e.FilamentDiameter / 2
+	return s.Length() * l.width * l.height / (math.Pi * r * r)
+}
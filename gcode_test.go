@@ -0,0 +1,58 @@
+package slice
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestEncodeLayer(t *testing.T) {
+	square := []*Segment{
+		{From: Vertex2{X: 0, Y: 0}, To: Vertex2{X: 0, Y: 10}},
+		{From: Vertex2{X: 0, Y: 10}, To: Vertex2{X: 10, Y: 10}},
+		{From: Vertex2{X: 10, Y: 10}, To: Vertex2{X: 10, Y: 0}},
+		{From: Vertex2{X: 10, Y: 0}, To: Vertex2{X: 0, Y: 0}},
+	}
+	l := &Layer{
+		z:       0.6,
+		height:  0.2,
+		width:   0.5,
+		speed:   40,
+		regions: []*Region{{Exterior: square}},
+	}
+
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	e.EncodeLayer(l)
+
+	sc := bufio.NewScanner(&buf)
+	sc.Scan()
+	if sc.Text() != "M83" {
+		t.Errorf("first line is %q, want relative extrusion", sc.Text())
+	}
+	sc.Scan()
+	if sc.Text() != "G1 Z0.60000 F2400" {
+		t.Errorf("second line is %q, want layer height", sc.Text())
+	}
+	var total float64
+	for sc.Scan() {
+		var x, y, e float64
+		if n, _ := fmt.Sscanf(sc.Text(), "G1 X%f Y%f E%f", &x, &y, &e); n == 3 {
+			total += e
+		}
+	}
+	r := 1.75 / 2
+	want := 40 * 0.5 * 0.2 / (math.Pi * r * r)
+	if !approxEquals(total, want, 1e-4) {
+		t.Errorf("extruded %f mm of filament, want %f", total, want)
+	}
+
+	// the extrusion mode is only set once
+	buf.Reset()
+	e.EncodeLayer(l)
+	if bytes.Contains(buf.Bytes(), []byte("M83")) {
+		t.Errorf("M83 repeated in second layer")
+	}
+}
@@ -0,0 +1,227 @@
+package slice
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"sigint.ca/slice/stl"
+)
+
+// A HeightRange overrides the layer height between two Z values, for
+// example to print fine detail with thinner layers. Min and Max are in the
+// coordinates of the solid, the same as Layer.Z.
+type HeightRange struct {
+	Min, Max float64
+	Height   float64
+}
+
+// epsilon absorbs floating point error accumulated while stacking layers,
+// so that range boundaries don't produce sliver layers.
+const epsilon = 1e-6
+
+func (r HeightRange) contains(z float64) bool {
+	return z >= r.Min-epsilon && z < r.Max-epsilon
+}
+
+// A span is the vertical extent of one layer.
+type span struct {
+	bottom, height float64
+}
+
+func (sp span) top() float64 { return sp.bottom + sp.height }
+
+// plane returns the height at which the layer is sliced: halfway
+// through, so that the outline is representative of the whole layer.
+func (sp span) plane() float64 { return sp.bottom + sp.height/2 }
+
+// checkHeights returns an error if cfg does not describe a usable
+// set of layer heights.
+func checkHeights(cfg Config) error {
+	if cfg.Adaptive {
+		if cfg.MinLayerHeight <= 0 || cfg.MaxLayerHeight < cfg.MinLayerHeight {
+			return fmt.Errorf("bad adaptive layer heights: min=%v max=%v", cfg.MinLayerHeight, cfg.MaxLayerHeight)
+		}
+	} else if cfg.LayerHeight <= 0 {
+		return fmt.Errorf("bad layer height: %v", cfg.LayerHeight)
+	}
+	if cfg.FirstLayerHeight < 0 {
+		return fmt.Errorf("bad first layer height: %v", cfg.FirstLayerHeight)
+	}
+	for i, r := range cfg.HeightRanges {
+		if r.Height <= 0 || r.Max <= r.Min {
+			return fmt.Errorf("bad height range %d: %v-%v at %v", i, r.Min, r.Max, r.Height)
+		}
+		for _, rr := range cfg.HeightRanges[:i] {
+			if r.Min < rr.Max && rr.Min < r.Max {
+				return fmt.Errorf("height range %d overlaps another range", i)
+			}
+		}
+	}
+	return nil
+}
+
+// layerSpans divides the height of s into layers according to cfg.
+//
+// Layers are cut short where a height range begins or ends, so that range
+// boundaries fall between layers. A cut which would leave a sliver thinner
+// than the layer's minimum (half its nominal height, or MinLayerHeight if
+// adaptive) is instead absorbed into the layer below: the two are merged,
+// or if that would be too thick, split evenly in two.
+func layerSpans(s *stl.Solid, cfg Config) []span {
+	min, max := s.Bounds()
+	z, top := min.Z, max.Z
+
+	spans := make([]span, 0)
+	limits := make([]limit, 0) // of each span, for absorbing slivers
+	if cfg.FirstLayerHeight != 0 {
+		spans = append(spans, span{bottom: z, height: cfg.FirstLayerHeight})
+		// the first layer is never thinned, only thickened
+		limits = append(limits, limit{low: cfg.FirstLayerHeight, high: math.Inf(+1)})
+		z += cfg.FirstLayerHeight
+	}
+
+	height := func(float64) float64 { return cfg.LayerHeight }
+	lim := limit{low: cfg.LayerHeight / 2, high: cfg.LayerHeight}
+	if cfg.Adaptive {
+		height = newAdaptive(s, cfg).height
+		lim = limit{low: cfg.MinLayerHeight, high: cfg.MaxLayerHeight}
+	}
+
+	for {
+		h, l := height(z), lim
+		end := math.Inf(+1) // boundary at which the layer must stop
+		if r, ok := rangeAt(cfg.HeightRanges, z); ok {
+			h, l, end = r.Height, limit{low: r.Height / 2, high: r.Height}, r.Max
+			if cfg.Adaptive {
+				l.low = math.Max(l.low, math.Min(r.Height, cfg.MinLayerHeight))
+			}
+		} else if next, ok := nextRange(cfg.HeightRanges, z); ok {
+			end = next.Min
+		}
+
+		if cfg.Adaptive {
+			if top-z <= cfg.MinLayerHeight/2 {
+				break
+			}
+			end = math.Min(end, top)
+		} else if top-z <= h/2 {
+			break
+		}
+
+		if z+h > end-epsilon {
+			cut := end - z
+			if cut >= l.low-epsilon {
+				h = cut
+			} else if n := len(spans); n > 0 {
+				prev, pl := spans[n-1], limits[n-1]
+				spans, limits = spans[:n-1], limits[:n-1]
+				total := prev.height + cut
+				if total <= pl.high+epsilon || total/2 < pl.low-epsilon {
+					spans = append(spans, span{bottom: prev.bottom, height: total})
+					limits = append(limits, pl)
+				} else {
+					spans = append(spans,
+						span{bottom: prev.bottom, height: total / 2},
+						span{bottom: prev.bottom + total/2, height: total / 2})
+					limits = append(limits, pl, pl)
+				}
+				z = end
+				continue
+			}
+			// otherwise there is no layer to absorb the sliver,
+			// so let this one overrun the boundary instead
+		}
+		spans = append(spans, span{bottom: z, height: h})
+		limits = append(limits, l)
+		z += h
+	}
+	return spans
+}
+
+// A limit gives the thinnest a layer may be, and the thickest it
+// may be made by absorbing a sliver.
+type limit struct {
+	low, high float64
+}
+
+// rangeAt returns the range containing z, if any.
+func rangeAt(ranges []HeightRange, z float64) (HeightRange, bool) {
+	for _, r := range ranges {
+		if r.contains(z) {
+			return r, true
+		}
+	}
+	return HeightRange{}, false
+}
+
+// nextRange returns the lowest range starting above z, if any.
+func nextRange(ranges []HeightRange, z float64) (HeightRange, bool) {
+	var next HeightRange
+	found := false
+	for _, r := range ranges {
+		if r.Min > z+epsilon && (!found || r.Min < next.Min) {
+			next, found = r, true
+		}
+	}
+	return next, found
+}
+
+// An adaptive chooses layer heights between cfg.MinLayerHeight and
+// cfg.MaxLayerHeight, so that the cusp left on sloped surfaces by the layer
+// edges does not exceed cfg.CuspHeight. Vertical walls get the thickest
+// layers, and nearly horizontal surfaces the thinnest.
+type adaptive struct {
+	min, max float64
+	cusp     float64
+	extents  []extent // sorted by lowest z
+}
+
+// An extent records the z range and slope of a facet.
+type extent struct {
+	min, max float64
+	nz       float64 // absolute z component of the facet normal
+}
+
+func newAdaptive(s *stl.Solid, cfg Config) *adaptive {
+	a := &adaptive{
+		min:     cfg.MinLayerHeight,
+		max:     cfg.MaxLayerHeight,
+		cusp:    cfg.CuspHeight,
+		extents: make([]extent, 0, len(s.Facets)),
+	}
+	if a.cusp == 0 {
+		a.cusp = cfg.MinLayerHeight
+	}
+
+	for _, f := range s.Facets {
+		nz := math.Abs(f.Normal.Z)
+		if math.IsNaN(nz) || nz > 0.999 {
+			// horizontal facets leave no cusps
+			continue
+		}
+		e := extent{min: math.Inf(+1), max: math.Inf(-1), nz: nz}
+		for _, v := range f.Vertices {
+			e.min = math.Min(e.min, v.Z)
+			e.max = math.Max(e.max, v.Z)
+		}
+		a.extents = append(a.extents, e)
+	}
+	sort.Slice(a.extents, func(i, j int) bool { return a.extents[i].min < a.extents[j].min })
+	return a
+}
+
+// height returns the height of a layer starting at z.
+func (a *adaptive) height(z float64) float64 {
+	h := a.max
+	for _, e := range a.extents {
+		if e.min >= z+a.max {
+			break
+		}
+		if e.max <= z || e.nz == 0 {
+			continue
+		}
+		h = math.Min(h, a.cusp/e.nz)
+	}
+	return math.Max(h, a.min)
+}
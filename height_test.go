@@ -0,0 +1,185 @@
+package slice
+
+import (
+	"math"
+	"os"
+	"testing"
+
+	"sigint.ca/slice/stl"
+)
+
+func parseFile(t testing.TB, path string) *stl.Solid {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	s, err := stl.Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// checkSpans checks that spans are contiguous and cover the height of s,
+// give or take tolerance.
+func checkSpans(t *testing.T, s *stl.Solid, spans []span, tolerance float64) {
+	min, max := s.Bounds()
+	z := min.Z
+	for i, sp := range spans {
+		if !approxEquals(sp.bottom, z, 1e-9) {
+			t.Fatalf("layer %d: bottom=%f, want %f", i, sp.bottom, z)
+		}
+		z = sp.top()
+	}
+	if !approxEquals(z, max.Z, tolerance) {
+		t.Errorf("layers end at z=%f, want %f", z, max.Z)
+	}
+}
+
+func TestUniformSpans(t *testing.T) {
+	s := parseFile(t, "testdata/pikachu.stl")
+	spans := layerSpans(s, Config{LayerHeight: 1.0})
+	if len(spans) != 59 {
+		t.Errorf("got %d layers, want 59", len(spans))
+	}
+	checkSpans(t, s, spans, 0.5)
+}
+
+func TestAdaptiveSpans(t *testing.T) {
+	cfg := Config{
+		Adaptive:       true,
+		MinLayerHeight: 0.1,
+		MaxLayerHeight: 0.3,
+	}
+
+	// a cube has only vertical walls, so all layers should be thick
+	cube := parseFile(t, "testdata/cube20_ascii.stl")
+	spans := layerSpans(cube, cfg)
+	checkSpans(t, cube, spans, cfg.MinLayerHeight/2)
+	for i, sp := range spans[:len(spans)-1] {
+		if !approxEquals(sp.height, cfg.MaxLayerHeight, 1e-9) {
+			t.Errorf("cube layer %d: height=%f, want %f", i, sp.height, cfg.MaxLayerHeight)
+		}
+	}
+
+	s := parseFile(t, "testdata/pikachu.stl")
+	spans = layerSpans(s, cfg)
+	checkSpans(t, s, spans, cfg.MinLayerHeight/2)
+	thin, thick := math.Inf(+1), math.Inf(-1)
+	for i, sp := range spans[:len(spans)-1] {
+		if sp.height < cfg.MinLayerHeight-1e-9 || sp.height > cfg.MaxLayerHeight+1e-9 {
+			t.Errorf("layer %d: height %f out of range", i, sp.height)
+		}
+		thin = math.Min(thin, sp.height)
+		thick = math.Max(thick, sp.height)
+	}
+	if thin == thick {
+		t.Errorf("all layers have height %f, want variation", thin)
+	}
+}
+
+func TestFirstLayer(t *testing.T) {
+	s := parseFile(t, "testdata/cube20_ascii.stl")
+	cfg := Config{
+		Logger:           discardLogger,
+		LayerHeight:      0.2,
+		LineWidth:        0.4,
+		Speed:            50,
+		FirstLayerHeight: 0.3,
+		FirstLayerWidth:  0.6,
+		FirstLayerSpeed:  20,
+	}
+	layers, _, err := Slice(s, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := layers[0]
+	if first.Height() != 0.3 || first.Z() != 0.3 || first.Width() != 0.6 || first.Speed() != 20 {
+		t.Errorf("first layer: z=%v height=%v width=%v speed=%v", first.Z(), first.Height(), first.Width(), first.Speed())
+	}
+	for i, l := range layers[1:] {
+		want := 0.3 + float64(i+1)*0.2
+		if !approxEquals(l.Z(), want, 1e-9) || l.Height() != 0.2 || l.Width() != 0.4 || l.Speed() != 50 {
+			t.Errorf("layer %d: z=%v (want %v) height=%v width=%v speed=%v", i+1, l.Z(), want, l.Height(), l.Width(), l.Speed())
+		}
+	}
+}
+
+func TestHeightRanges(t *testing.T) {
+	s := parseFile(t, "testdata/cube20_ascii.stl")
+	fine := HeightRange{Min: 12, Max: 18, Height: 0.1}
+	cfg := Config{
+		LayerHeight:  0.3,
+		HeightRanges: []HeightRange{fine},
+	}
+	if err := checkHeights(cfg); err != nil {
+		t.Fatal(err)
+	}
+	spans := layerSpans(s, cfg)
+	checkSpans(t, s, spans, 0.15)
+
+	var bottom, top bool
+	for i, sp := range spans {
+		mid := sp.plane()
+		switch {
+		case mid > fine.Min && mid < fine.Max:
+			if !approxEquals(sp.height, fine.Height, 1e-9) {
+				t.Errorf("layer %d at z=%f: height=%f, want %f", i, sp.bottom, sp.height, fine.Height)
+			}
+		case sp.height > cfg.LayerHeight+1e-9:
+			t.Errorf("layer %d at z=%f: height=%f, want at most %f", i, sp.bottom, sp.height, cfg.LayerHeight)
+		}
+		bottom = bottom || approxEquals(sp.bottom, fine.Min, 1e-9)
+		top = top || approxEquals(sp.top(), fine.Max, 1e-9)
+	}
+	if !bottom || !top {
+		t.Errorf("range boundaries do not coincide with layer boundaries")
+	}
+
+	cfg.HeightRanges = append(cfg.HeightRanges, HeightRange{Min: 17, Max: 19, Height: 0.2})
+	if _, _, err := Slice(s, cfg); err == nil {
+		t.Error("overlapping ranges were accepted")
+	}
+}
+
+func TestHeightRangeSlivers(t *testing.T) {
+	s := parseFile(t, "testdata/cube20_ascii.stl")
+	ranges := []HeightRange{
+		{Min: 12.01, Max: 18, Height: 0.1},
+		{Min: 12, Max: 18.02, Height: 0.1},
+		{Min: 12.01, Max: 18.02, Height: 0.1},
+		{Min: 5.05, Max: 5.26, Height: 0.2},
+	}
+	configs := []Config{
+		{LayerHeight: 0.3},
+		{Adaptive: true, MinLayerHeight: 0.1, MaxLayerHeight: 0.3},
+	}
+	for _, fine := range ranges {
+		for _, cfg := range configs {
+			cfg.HeightRanges = []HeightRange{fine}
+			spans := layerSpans(s, cfg)
+			checkSpans(t, s, spans, 0.15)
+			var bottom, top bool
+			for i, sp := range spans {
+				min := cfg.LayerHeight / 2
+				if mid := sp.plane(); mid > fine.Min && mid < fine.Max {
+					min = fine.Height / 2
+				}
+				if cfg.Adaptive {
+					min = cfg.MinLayerHeight
+				}
+				if sp.height < min-1e-9 {
+					t.Errorf("range %v, adaptive=%v: layer %d at z=%f: height=%f, want at least %f",
+						fine, cfg.Adaptive, i, sp.bottom, sp.height, min)
+				}
+				bottom = bottom || approxEquals(sp.bottom, fine.Min, 1e-9)
+				top = top || approxEquals(sp.top(), fine.Max, 1e-9)
+			}
+			if !bottom || !top {
+				t.Errorf("range %v, adaptive=%v: range boundaries do not coincide with layer boundaries", fine, cfg.Adaptive)
+			}
+		}
+	}
+}
@@ -3,13 +3,43 @@ package slice
 import "sigint.ca/slice/stl"
 
 type Layer struct {
-	n           int        // layer index
-	z           float64    // layer z value
-	stl         *stl.Solid // the parent STL
-	regions     []*Region  // one self-contained object, from the layer perspective
-	scaleFactor float64    // for drawing
+	n           int         // layer index
+	z           float64     // z value of the top of the layer
+	height      float64     // layer thickness
+	width       float64     // extrusion line width
+	speed       float64     // print speed in mm/s
+	stl         *stl.Solid  // the parent STL
+	regions     []*Region   // one self-contained object, from the layer perspective
+	diags       Diagnostics // problems encountered while slicing this layer
+	scaleFactor float64     // for drawing
 }
 
 func (l *Layer) Regions() []*Region {
 	return l.regions
 }
+
+// Z returns the height of the top of the layer, which is where
+// the nozzle sits while printing it.
+func (l *Layer) Z() float64 {
+	return l.z
+}
+
+// Height returns the thickness of the layer.
+func (l *Layer) Height() float64 {
+	return l.height
+}
+
+// Width returns the extrusion line width used for the layer.
+func (l *Layer) Width() float64 {
+	return l.width
+}
+
+// Speed returns the print speed for the layer in mm/s.
+func (l *Layer) Speed() float64 {
+	return l.speed
+}
+
+// Diagnostics returns the problems encountered while slicing l.
+func (l *Layer) Diagnostics() Diagnostics {
+	return l.diags
+}
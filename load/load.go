@@ -0,0 +1,175 @@
+// Package load reads solids from 3D model files, detecting their format
+// from their content.
+package load
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"sigint.ca/slice/amf"
+	"sigint.ca/slice/obj"
+	"sigint.ca/slice/ply"
+	"sigint.ca/slice/stl"
+	"sigint.ca/slice/threemf"
+)
+
+// A Format is a 3D model file format.
+type Format int
+
+const (
+	STL Format = iota
+	OBJ
+	PLY
+	AMF
+	ThreeMF
+)
+
+func (f Format) String() string {
+	switch f {
+	case STL:
+		return "STL"
+	case OBJ:
+		return "OBJ"
+	case PLY:
+		return "PLY"
+	case AMF:
+		return "AMF"
+	case ThreeMF:
+		return "3MF"
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// zipFormat is a stand-in for AMF or 3MF until the archive is opened.
+const zipFormat Format = -1
+
+// Read reads the solids from a model file in any of the supported formats,
+// and returns them along with the detected format. STL and PLY files always
+// hold a single solid; the others may hold any number.
+//
+// Zipped formats (3MF and compressed AMF) are read into memory in full.
+//
+// If r is an io.ReadSeeker, such as an *os.File, it is passed on as is
+// once the format is detected, so that the STL parser can check the
+// facet count given in a binary header against the size of the file.
+func Read(r io.Reader) ([]*stl.Solid, Format, error) {
+	top, r, err := peek(r, 512)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	f := detect(top)
+	var solids []*stl.Solid
+	switch f {
+	case zipFormat:
+		return readZip(r)
+	case OBJ:
+		solids, err = obj.Parse(r)
+	case PLY:
+		var s *stl.Solid
+		if s, err = ply.Parse(r); err == nil {
+			solids = []*stl.Solid{s}
+		}
+	case AMF:
+		solids, err = amf.Parse(r)
+	default:
+		var s *stl.Solid
+		if s, err = stl.Parse(r); err == nil {
+			solids = []*stl.Solid{s}
+		}
+	}
+	if err != nil {
+		return nil, f, err
+	}
+	return solids, f, nil
+}
+
+// peek returns up to the first n bytes of r, and a reader which yields
+// all of r, those bytes included. A seekable r is read from and then
+// rewound, and returned itself; anything else is buffered.
+func peek(r io.Reader, n int) ([]byte, io.Reader, error) {
+	rs, ok := r.(io.ReadSeeker)
+	if !ok {
+		br := bufio.NewReaderSize(r, n)
+		top, err := br.Peek(n)
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return nil, nil, err
+		}
+		return top, br, nil
+	}
+
+	start, err := rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, nil, err
+	}
+	top := make([]byte, n)
+	m, err := io.ReadFull(rs, top)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, nil, err
+	}
+	if _, err := rs.Seek(start, io.SeekStart); err != nil {
+		return nil, nil, err
+	}
+	return top[:m], rs, nil
+}
+
+// readZip reads a 3MF package or a compressed AMF file.
+func readZip(r io.Reader) ([]*stl.Solid, Format, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, ThreeMF, err
+	}
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, ThreeMF, fmt.Errorf("error decoding zip archive: %v", err)
+	}
+	for _, f := range zr.File {
+		if f.Name == "[Content_Types].xml" {
+			solids, err := threemf.Parse(bytes.NewReader(b), int64(len(b)))
+			return solids, ThreeMF, err
+		}
+	}
+	solids, err := amf.Parse(bytes.NewReader(b))
+	return solids, AMF, err
+}
+
+// detect guesses the format of a file from its first few hundred bytes.
+func detect(top []byte) Format {
+	if bytes.HasPrefix(top, []byte("PK\x03\x04")) {
+		return zipFormat
+	}
+	text := strings.TrimLeft(strings.TrimPrefix(string(top), "\ufeff"), " \t\r\n")
+	switch {
+	case strings.HasPrefix(text, "ply\n"), strings.HasPrefix(text, "ply\r\n"):
+		return PLY
+	case strings.HasPrefix(text, "<?xml"), strings.HasPrefix(text, "<amf"):
+		return AMF
+	case strings.HasPrefix(text, "solid"):
+		// ASCII, or binary with an unhelpful header; stl tells them apart
+		return STL
+	}
+
+	// binary STLs are full of control characters
+	for _, c := range top {
+		if c < ' ' && c != '\t' && c != '\r' && c != '\n' {
+			return STL
+		}
+	}
+	// look for an OBJ statement, skipping comments
+	for _, line := range strings.Split(text, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		switch fields[0] {
+		case "v", "vt", "vn", "f", "o", "g", "s", "mtllib", "usemtl":
+			return OBJ
+		}
+		break
+	}
+	return STL
+}
@@ -0,0 +1,139 @@
+package load
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sigint.ca/slice/stl"
+	"sigint.ca/slice/threemf"
+)
+
+const (
+	objTriangle = "# a triangle\n\nv 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 3\n"
+	plyTriangle = "ply\nformat ascii 1.0\nelement vertex 3\nproperty float x\nproperty float y\nproperty float z\n" +
+		"element face 1\nproperty list uchar int vertex_indices\nend_header\n0 0 0\n1 0 0\n0 1 0\n3 0 1 2\n"
+	amfTriangle = `<?xml version="1.0"?><amf><object id="0"><mesh><vertices>
+<vertex><coordinates><x>0</x><y>0</y><z>0</z></coordinates></vertex>
+<vertex><coordinates><x>1</x><y>0</y><z>0</z></coordinates></vertex>
+<vertex><coordinates><x>0</x><y>1</y><z>0</z></coordinates></vertex>
+</vertices><volume><triangle><v1>0</v1><v2>1</v2><v3>2</v3></triangle></volume></mesh></object></amf>`
+)
+
+func readFile(t *testing.T, name string) string {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestRead(t *testing.T) {
+	ascii := readFile(t, "../testdata/cube20_ascii.stl")
+	cube, err := stl.Parse(strings.NewReader(ascii))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pkg bytes.Buffer
+	if err := threemf.Encode(&pkg, &threemf.Package{Solids: []*stl.Solid{cube, cube}}); err != nil {
+		t.Fatal(err)
+	}
+	var zipped bytes.Buffer
+	zw := zip.NewWriter(&zipped)
+	w, err := zw.Create("triangle.amf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte(amfTriangle)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		data   string
+		format Format
+		solids int
+		facets int // in the first solid
+	}{
+		{"ascii stl", ascii, STL, 1, 12},
+		{"binary stl", readFile(t, "../testdata/cube40_binary.stl"), STL, 1, 12},
+		{"stl with solid header", readFile(t, "../testdata/cube20_solid_header.stl"), STL, 1, 12},
+		{"obj", objTriangle, OBJ, 1, 1},
+		{"ply", plyTriangle, PLY, 1, 1},
+		{"amf", amfTriangle, AMF, 1, 1},
+		{"zipped amf", zipped.String(), AMF, 1, 1},
+		{"3mf", pkg.String(), ThreeMF, 2, 12},
+	}
+	for _, test := range tests {
+		solids, f, err := Read(strings.NewReader(test.data))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if f != test.format {
+			t.Errorf("%s: detected %v, want %v", test.name, f, test.format)
+		}
+		if len(solids) != test.solids {
+			t.Errorf("%s: got %d solids, want %d", test.name, len(solids), test.solids)
+		} else if len(solids[0].Facets) != test.facets {
+			t.Errorf("%s: got %d facets, want %d", test.name, len(solids[0].Facets), test.facets)
+		}
+	}
+}
+
+func TestReadSize(t *testing.T) {
+	// a binary STL whose header claims far more facets than it holds
+	b := []byte(readFile(t, "../testdata/cube40_binary.stl"))
+	binary.LittleEndian.PutUint32(b[80:], 1<<30)
+
+	_, _, err := Read(bytes.NewReader(b))
+	if err == nil || !strings.Contains(err.Error(), "header claims") {
+		t.Errorf("bytes.Reader: got error %v, want bad facet count", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "bad.stl")
+	if err := os.WriteFile(path, b, 0666); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	_, _, err = Read(f)
+	if err == nil || !strings.Contains(err.Error(), "header claims") {
+		t.Errorf("os.File: got error %v, want bad facet count", err)
+	}
+
+	// the peeked bytes are read again by the parser
+	solids, _, err := Read(bytes.NewReader([]byte(readFile(t, "../testdata/cube40_binary.stl"))))
+	if err != nil || len(solids) != 1 || len(solids[0].Facets) != 12 {
+		t.Errorf("got %d solids, error %v", len(solids), err)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		top  string
+		want Format
+	}{
+		{"\ufeff<?xml version=\"1.0\"?>\n<amf>", AMF},
+		{"\r\nply\r\nformat ascii 1.0", PLY},
+		{"# comment\n#another\nmtllib cube.mtl\n", OBJ},
+		{"exported by foo\x00\x00\x0c\x00\x00\x00", STL},
+		{"nothing we know", STL},
+		{"", STL},
+	}
+	for _, test := range tests {
+		if got := detect([]byte(test.top)); got != test.want {
+			t.Errorf("detect(%q) = %v, want %v", test.top, got, test.want)
+		}
+	}
+}
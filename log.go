@@ -0,0 +1,78 @@
+package slice
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// Slicing stages, used to annotate log records and diagnostics.
+const (
+	stageSlice      = "slice"
+	stagePerimeters = "perimeters"
+	stageRegions    = "regions"
+	stageInfill     = "infill"
+)
+
+// A logger reports debugging output and diagnostics for a single layer
+// of a slicing job. The zero value discards everything.
+type logger struct {
+	log   *slog.Logger
+	layer int
+	z     float64
+	stage string
+	diags *Diagnostics // diagnostics are collected here, if non-nil
+}
+
+func newLogger(log *slog.Logger, layer int, z float64, diags *Diagnostics) logger {
+	return logger{
+		log:   log.With("layer", layer),
+		layer: layer,
+		z:     z,
+		diags: diags,
+	}
+}
+
+// at returns a copy of l which annotates its output with stage.
+func (l logger) at(stage string) logger {
+	l.stage = stage
+	return l
+}
+
+func (l logger) debugf(format string, args ...interface{}) {
+	if l.log == nil || !l.log.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	l.log.Debug(fmt.Sprintf(format, args...), "stage", l.stage)
+}
+
+// report logs d and adds it to the layer's diagnostics.
+func (l logger) report(d Diagnostic) {
+	d.Layer = l.layer
+	d.Z = l.z
+	d.Stage = l.stage
+	if d.Msg == "" {
+		d.Msg = d.Kind.String()
+	}
+	if l.log != nil {
+		attrs := []interface{}{"stage", l.stage, "kind", d.Kind.String(), "x", d.Location.X, "y", d.Location.Y}
+		if d.Facet >= 0 {
+			attrs = append(attrs, "facet", d.Facet)
+		}
+		l.log.Log(context.Background(), d.Kind.level(), d.Msg, attrs...)
+	}
+	if l.diags != nil {
+		*l.diags = append(*l.diags, d)
+	}
+}
+
+// defaultLogger returns the logger used when Config.Logger is nil. It writes
+// warnings to stderr, and debugging output as well in debug mode.
+func defaultLogger(debug bool) *slog.Logger {
+	level := slog.LevelWarn
+	if debug {
+		level = slog.LevelDebug
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+}
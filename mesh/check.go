@@ -0,0 +1,341 @@
+package mesh
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"sigint.ca/slice/stl"
+	"sigint.ca/slice/vector"
+)
+
+// A Kind identifies the type of defect described by a Problem.
+type Kind int
+
+const (
+	NonManifoldEdge  Kind = iota + 1 // an edge borders more than two facets
+	BoundaryEdge                     // an edge borders only one facet, leaving a hole
+	FlippedFacet                     // a facet is wound against its neighbors
+	DegenerateFacet                  // a facet has (nearly) zero area
+	DuplicateFacet                   // a facet has the same vertices as an earlier one
+	SelfIntersection                 // two facets which share no vertices intersect
+)
+
+var kindNames = map[Kind]string{
+	NonManifoldEdge:  "non-manifold edge",
+	BoundaryEdge:     "boundary edge",
+	FlippedFacet:     "flipped facet",
+	DegenerateFacet:  "degenerate facet",
+	DuplicateFacet:   "duplicate facet",
+	SelfIntersection: "self-intersection",
+}
+
+func (k Kind) String() string {
+	if s, ok := kindNames[k]; ok {
+		return s
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// A Problem describes a defect in a mesh.
+type Problem struct {
+	Kind     Kind
+	Facets   []int     // indices of the facets involved
+	Location vector.V3 // approximate position of the problem
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%v at %v (facets %v)", p.Kind, p.Location, p.Facets)
+}
+
+// Problems is a list of defects found in a mesh, grouped by kind.
+type Problems []Problem
+
+// Filter returns the problems of the given kinds.
+func (ps Problems) Filter(kinds ...Kind) Problems {
+	var out Problems
+	for _, p := range ps {
+		for _, k := range kinds {
+			if p.Kind == k {
+				out = append(out, p)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// Count returns the number of problems of each kind.
+func (ps Problems) Count() map[Kind]int {
+	m := make(map[Kind]int)
+	for _, p := range ps {
+		m[p.Kind]++
+	}
+	return m
+}
+
+// Err returns an error summarizing the problems, or nil if there are none.
+func (ps Problems) Err() error {
+	switch len(ps) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("broken mesh: %v", ps[0])
+	}
+	return fmt.Errorf("broken mesh: %v (and %d more problems)", ps[0], len(ps)-1)
+}
+
+// Check welds the vertices of s which are within tol of each other, and
+// returns the defects in the resulting mesh. Facet indices refer to s.
+func Check(s *stl.Solid, tol float64) Problems {
+	return FromSolid(s, tol).Check()
+}
+
+// Check returns the defects in m. Facet indices are indices into m.Faces.
+func (m *Mesh) Check() Problems {
+	var ps Problems
+	ps = append(ps, m.checkEdges()...)
+	ps = append(ps, m.checkOrientation()...)
+	ps = append(ps, m.checkFaces()...)
+	ps = append(ps, m.checkIntersections()...)
+	return ps
+}
+
+// checkEdges reports edges which don't border exactly two faces.
+func (m *Mesh) checkEdges() Problems {
+	var ps Problems
+	for _, e := range m.Edges() {
+		faces := m.edges[e]
+		if len(faces) == 2 {
+			continue
+		}
+		p := Problem{
+			Kind:     BoundaryEdge,
+			Facets:   append([]int(nil), faces...),
+			Location: m.Vertices[e.A].Add(m.Vertices[e.B]).Mul(0.5),
+		}
+		if len(faces) > 2 {
+			p.Kind = NonManifoldEdge
+		}
+		ps = append(ps, p)
+	}
+	return ps
+}
+
+// checkOrientation reports faces whose winding disagrees with that of the
+// rest of their shell. Each shell is oriented outwards if it is closed,
+// or otherwise to agree with the majority of its faces.
+func (m *Mesh) checkOrientation() Problems {
+	flipped := make([]bool, len(m.Faces)) // relative to the first face of the shell
+	seen := make([]bool, len(m.Faces))
+	var ps Problems
+	for start, f := range m.Faces {
+		if seen[start] || collapsed(f) {
+			continue
+		}
+		seen[start] = true
+		shell := []int{start}
+		closed := true
+		for i := 0; i < len(shell); i++ {
+			f := shell[i]
+			for j, n := range m.Neighbors(f) {
+				if n < 0 {
+					if len(m.edges[m.FaceEdges(f)[j]]) == 1 {
+						closed = false
+					}
+					continue
+				}
+				if seen[n] {
+					continue
+				}
+				seen[n] = true
+				flipped[n] = flipped[f] != m.sameDirection(f, n, j)
+				shell = append(shell, n)
+			}
+		}
+
+		var volume float64
+		var nflipped int
+		for _, f := range shell {
+			v := m.signedVolume(f)
+			if flipped[f] {
+				v = -v
+				nflipped++
+			}
+			volume += v
+		}
+		// wrong is the value of flipped for faces with the wrong winding
+		wrong := nflipped*2 < len(shell)
+		if closed && volume != 0 {
+			wrong = volume > 0
+		}
+		for _, f := range shell {
+			if flipped[f] == wrong {
+				ps = append(ps, Problem{
+					Kind:     FlippedFacet,
+					Facets:   []int{f},
+					Location: m.centroid(f),
+				})
+			}
+		}
+	}
+	sort.Slice(ps, func(i, j int) bool { return ps[i].Facets[0] < ps[j].Facets[0] })
+	return ps
+}
+
+// sameDirection reports whether face n traverses the j'th edge of face f
+// in the same direction as f does, meaning that they are wound oppositely.
+func (m *Mesh) sameDirection(f, n, j int) bool {
+	a, b := m.Faces[f][j], m.Faces[f][(j+1)%3]
+	g := m.Faces[n]
+	for k := range g {
+		if g[k] == a && g[(k+1)%3] == b {
+			return true
+		}
+	}
+	return false
+}
+
+// signedVolume returns the signed volume of the tetrahedron formed by face
+// i and the origin. Summed over a closed shell, it is positive if the shell
+// is wound outwards.
+func (m *Mesh) signedVolume(i int) float64 {
+	f := m.Faces[i]
+	a, b, c := m.Vertices[f[0]], m.Vertices[f[1]], m.Vertices[f[2]]
+	return a.Dot(b.Cross(c)) / 6
+}
+
+func (m *Mesh) centroid(i int) vector.V3 {
+	f := m.Faces[i]
+	return m.Vertices[f[0]].Add(m.Vertices[f[1]]).Add(m.Vertices[f[2]]).Mul(1.0 / 3)
+}
+
+// checkFaces reports degenerate and duplicate faces.
+func (m *Mesh) checkFaces() Problems {
+	var ps Problems
+	first := make(map[[3]int]int) // first face with each set of vertices
+	for i, f := range m.Faces {
+		if collapsed(f) || !(m.cross(i).Length() > 1e-12) {
+			ps = append(ps, Problem{Kind: DegenerateFacet, Facets: []int{i}, Location: m.centroid(i)})
+			continue
+		}
+		key := f
+		sort.Ints(key[:])
+		if j, ok := first[key]; ok {
+			ps = append(ps, Problem{Kind: DuplicateFacet, Facets: []int{j, i}, Location: m.centroid(i)})
+		} else {
+			first[key] = i
+		}
+	}
+	return ps
+}
+
+// checkIntersections reports pairs of faces which intersect, other than
+// along shared edges or at shared vertices. Faces which share a vertex are
+// not tested, nor are coplanar faces, which can only overlap.
+func (m *Mesh) checkIntersections() Problems {
+	type box struct {
+		face     int
+		min, max vector.V3
+	}
+	boxes := make([]box, len(m.Faces))
+	for i, f := range m.Faces {
+		b := box{face: i, min: m.Vertices[f[0]], max: m.Vertices[f[0]]}
+		for _, vi := range f[1:] {
+			v := m.Vertices[vi]
+			b.min = vector.V3{X: math.Min(b.min.X, v.X), Y: math.Min(b.min.Y, v.Y), Z: math.Min(b.min.Z, v.Z)}
+			b.max = vector.V3{X: math.Max(b.max.X, v.X), Y: math.Max(b.max.Y, v.Y), Z: math.Max(b.max.Z, v.Z)}
+		}
+		boxes[i] = b
+	}
+	sort.Slice(boxes, func(i, j int) bool { return boxes[i].min.X < boxes[j].min.X })
+
+	// sweep along x, testing faces whose bounding boxes overlap
+	var ps Problems
+	for i, a := range boxes {
+		for _, b := range boxes[i+1:] {
+			if b.min.X > a.max.X {
+				break
+			}
+			if b.min.Y > a.max.Y || a.min.Y > b.max.Y || b.min.Z > a.max.Z || a.min.Z > b.max.Z {
+				continue
+			}
+			if m.shareVertex(a.face, b.face) {
+				continue
+			}
+			if p, ok := m.intersect(a.face, b.face); ok {
+				f, g := a.face, b.face
+				if f > g {
+					f, g = g, f
+				}
+				ps = append(ps, Problem{Kind: SelfIntersection, Facets: []int{f, g}, Location: p})
+			}
+		}
+	}
+	sort.Slice(ps, func(i, j int) bool {
+		if ps[i].Facets[0] != ps[j].Facets[0] {
+			return ps[i].Facets[0] < ps[j].Facets[0]
+		}
+		return ps[i].Facets[1] < ps[j].Facets[1]
+	})
+	return ps
+}
+
+func (m *Mesh) shareVertex(i, j int) bool {
+	for _, a := range m.Faces[i] {
+		for _, b := range m.Faces[j] {
+			if a == b {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// intersect returns a point at which faces i and j intersect, if they do.
+// Two triangles which aren't coplanar intersect if and only if an edge
+// of one passes through the other.
+func (m *Mesh) intersect(i, j int) (vector.V3, bool) {
+	for _, pair := range [2][2]int{{i, j}, {j, i}} {
+		f := m.Faces[pair[0]]
+		for k := range f {
+			a, b := m.Vertices[f[k]], m.Vertices[f[(k+1)%3]]
+			if p, ok := m.segmentHits(a, b, pair[1]); ok {
+				return p, true
+			}
+		}
+	}
+	return vector.V3{}, false
+}
+
+// segmentHits returns the point at which the segment from a to b passes
+// through the interior of face i, using the Möller–Trumbore algorithm.
+// Segments which only touch the face, or lie in its plane, don't count.
+func (m *Mesh) segmentHits(a, b vector.V3, i int) (vector.V3, bool) {
+	const eps = 1e-9
+	f := m.Faces[i]
+	v0, v1, v2 := m.Vertices[f[0]], m.Vertices[f[1]], m.Vertices[f[2]]
+	e1, e2 := v1.Sub(v0), v2.Sub(v0)
+	dir := b.Sub(a)
+
+	h := dir.Cross(e2)
+	det := e1.Dot(h)
+	if math.Abs(det) < eps*e1.Length()*e2.Length()*dir.Length() {
+		return vector.V3{}, false // parallel
+	}
+	s := a.Sub(v0)
+	u := s.Dot(h) / det
+	if u <= eps || u >= 1-eps {
+		return vector.V3{}, false
+	}
+	q := s.Cross(e1)
+	v := dir.Dot(q) / det
+	if v <= eps || u+v >= 1-eps {
+		return vector.V3{}, false
+	}
+	t := e2.Dot(q) / det
+	if t <= eps || t >= 1-eps {
+		return vector.V3{}, false
+	}
+	return a.Add(dir.Mul(t)), true
+}
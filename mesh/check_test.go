@@ -0,0 +1,102 @@
+package mesh
+
+import (
+	"reflect"
+	"testing"
+
+	"sigint.ca/slice/stl"
+	"sigint.ca/slice/vector"
+)
+
+// modified returns a copy of s with its facets changed by fn.
+func modified(s *stl.Solid, fn func([]stl.Facet) []stl.Facet) *stl.Solid {
+	m := stl.NewSolid(fn(append([]stl.Facet(nil), s.Facets...)))
+	m.Name = s.Name
+	return m
+}
+
+func moved(facets []stl.Facet, d vector.V3) []stl.Facet {
+	out := make([]stl.Facet, len(facets))
+	for i, f := range facets {
+		for j, v := range f.Vertices {
+			out[i].Vertices[j] = v.Add(d)
+		}
+	}
+	return out
+}
+
+func TestCheck(t *testing.T) {
+	cube := parseFile(t, "../testdata/cube20_ascii.stl")
+	if ps := Check(cube, 0); ps != nil {
+		t.Fatalf("clean cube has problems: %v", ps)
+	}
+
+	tests := []struct {
+		name  string
+		solid *stl.Solid
+		want  map[Kind]int
+		first []int // facets of the first problem
+	}{
+		{
+			"open",
+			modified(cube, func(fs []stl.Facet) []stl.Facet { return fs[1:] }),
+			map[Kind]int{BoundaryEdge: 3},
+			nil,
+		},
+		{
+			"flipped",
+			modified(cube, func(fs []stl.Facet) []stl.Facet {
+				fs[5].Vertices[1], fs[5].Vertices[2] = fs[5].Vertices[2], fs[5].Vertices[1]
+				return fs
+			}),
+			map[Kind]int{FlippedFacet: 1},
+			[]int{5},
+		},
+		{
+			"duplicate",
+			modified(cube, func(fs []stl.Facet) []stl.Facet { return append(fs, fs[3]) }),
+			map[Kind]int{DuplicateFacet: 1, NonManifoldEdge: 3},
+			nil,
+		},
+		{
+			"degenerate",
+			modified(cube, func(fs []stl.Facet) []stl.Facet {
+				v, w := fs[0].Vertices[0], fs[0].Vertices[1]
+				return append(fs, stl.Facet{Vertices: [3]vector.V3{v, w, v}})
+			}),
+			map[Kind]int{DegenerateFacet: 1},
+			[]int{12},
+		},
+		{
+			"disjoint",
+			modified(cube, func(fs []stl.Facet) []stl.Facet { return append(fs, moved(fs, vector.V3{X: 30})...) }),
+			map[Kind]int{},
+			nil,
+		},
+	}
+	for _, test := range tests {
+		ps := Check(test.solid, 0)
+		if got := ps.Count(); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v\n%v", test.name, got, test.want, ps)
+			continue
+		}
+		if test.first != nil && !reflect.DeepEqual(ps[0].Facets, test.first) {
+			t.Errorf("%s: got problem %v, want facets %v", test.name, ps[0], test.first)
+		}
+		if (ps.Err() == nil) != (len(ps) == 0) {
+			t.Errorf("%s: got error %v with %d problems", test.name, ps.Err(), len(ps))
+		}
+	}
+
+	// every intersection is between a facet of each cube
+	overlapping := modified(cube, func(fs []stl.Facet) []stl.Facet { return append(fs, moved(fs, vector.V3{X: 10, Y: 7, Z: 13})...) })
+	ps := Check(overlapping, 0)
+	if len(ps) == 0 {
+		t.Fatal("overlapping cubes: no problems")
+	}
+	for _, p := range ps {
+		if p.Kind != SelfIntersection || p.Facets[0] >= 12 || p.Facets[1] < 12 {
+			t.Errorf("overlapping cubes: got %v", p)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+// Package mesh provides an indexed triangle mesh, in which facets share
+// vertices and the facets bordering each edge are known.
+package mesh
+
+import (
+	"math"
+	"sort"
+
+	"sigint.ca/slice/stl"
+	"sigint.ca/slice/vector"
+)
+
+// A Mesh is a set of triangular faces over a shared list of vertices.
+// Faces are wound counter-clockwise when viewed from outside.
+type Mesh struct {
+	Name     string
+	Vertices []vector.V3
+	Faces    [][3]int // indices into Vertices
+
+	edges map[Edge][]int // faces bordering each edge
+}
+
+// An Edge joins two vertices, given by index with the lower one first.
+type Edge struct {
+	A, B int
+}
+
+// edge returns the edge between vertices a and b.
+func edge(a, b int) Edge {
+	if a > b {
+		a, b = b, a
+	}
+	return Edge{a, b}
+}
+
+// New returns a mesh with the given vertices and faces.
+func New(vertices []vector.V3, faces [][3]int) *Mesh {
+	m := &Mesh{
+		Vertices: vertices,
+		Faces:    faces,
+	}
+	m.Update()
+	return m
+}
+
+// FromSolid returns a mesh of the facets of s, welding together vertices
+// which are within tol of each other. Face i of the mesh is facet i of s;
+// facets which collapse when welded are kept, with repeated vertices.
+func FromSolid(s *stl.Solid, tol float64) *Mesh {
+	w := newWelder(tol)
+	faces := make([][3]int, len(s.Facets))
+	for i, f := range s.Facets {
+		for j, v := range f.Vertices {
+			faces[i][j] = w.add(v)
+		}
+	}
+	m := New(w.vertices, faces)
+	m.Name = s.Name
+	return m
+}
+
+// Solid returns a solid with a facet for each face of m,
+// with normals following their winding.
+func (m *Mesh) Solid() *stl.Solid {
+	facets := make([]stl.Facet, len(m.Faces))
+	for i, f := range m.Faces {
+		for j, vi := range f {
+			facets[i].Vertices[j] = m.Vertices[vi]
+		}
+	}
+	s := stl.NewSolid(facets)
+	s.Name = m.Name
+	s.ReconcileNormals(stl.TrustWinding)
+	return s
+}
+
+// Update brings the adjacency information up to date. It must be
+// called after modifying Vertices or Faces.
+func (m *Mesh) Update() {
+	m.edges = make(map[Edge][]int, len(m.Faces)*3/2)
+	for i, f := range m.Faces {
+		if collapsed(f) {
+			continue
+		}
+		for _, e := range m.FaceEdges(i) {
+			m.edges[e] = append(m.edges[e], i)
+		}
+	}
+}
+
+// collapsed reports whether f has repeated vertices. Collapsed faces
+// have no area, and are left out of the adjacency information.
+func collapsed(f [3]int) bool {
+	return f[0] == f[1] || f[1] == f[2] || f[2] == f[0]
+}
+
+// FaceEdges returns the edges of face i, starting with the edge from its
+// first vertex to its second.
+func (m *Mesh) FaceEdges(i int) [3]Edge {
+	f := m.Faces[i]
+	return [3]Edge{edge(f[0], f[1]), edge(f[1], f[2]), edge(f[2], f[0])}
+}
+
+// Edges returns all of the edges of m, sorted by vertex index.
+func (m *Mesh) Edges() []Edge {
+	edges := make([]Edge, 0, len(m.edges))
+	for e := range m.edges {
+		edges = append(edges, e)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].A != edges[j].A {
+			return edges[i].A < edges[j].A
+		}
+		return edges[i].B < edges[j].B
+	})
+	return edges
+}
+
+// EdgeFaces returns the faces bordering e, which has two
+// in a closed, manifold mesh. The result must not be modified.
+func (m *Mesh) EdgeFaces(e Edge) []int {
+	return m.edges[edge(e.A, e.B)]
+}
+
+// Neighbors returns the faces across each of the edges of face i, in
+// the order of FaceEdges, or -1 where there isn't exactly one.
+func (m *Mesh) Neighbors(i int) [3]int {
+	n := [3]int{-1, -1, -1}
+	if collapsed(m.Faces[i]) {
+		return n
+	}
+	for j, e := range m.FaceEdges(i) {
+		faces := m.edges[e]
+		if len(faces) != 2 {
+			continue
+		}
+		if faces[0] == i {
+			n[j] = faces[1]
+		} else {
+			n[j] = faces[0]
+		}
+	}
+	return n
+}
+
+// FaceNormal returns the unit normal of face i according to its winding.
+// It is NaN for degenerate faces.
+func (m *Mesh) FaceNormal(i int) vector.V3 {
+	return m.cross(i).Normalize()
+}
+
+// cross returns the cross product of two edges of face i,
+// whose length is twice the face's area.
+func (m *Mesh) cross(i int) vector.V3 {
+	f := m.Faces[i]
+	a, b, c := m.Vertices[f[0]], m.Vertices[f[1]], m.Vertices[f[2]]
+	return b.Sub(a).Cross(c.Sub(a))
+}
+
+// A welder merges vertices within a tolerance of each other, using a
+// grid of cells the size of the tolerance to find nearby vertices.
+type welder struct {
+	tol      float64
+	vertices []vector.V3
+	exact    map[vector.V3]int
+	cells    map[[3]int64][]int
+}
+
+func newWelder(tol float64) *welder {
+	w := &welder{tol: tol}
+	if tol > 0 {
+		w.cells = make(map[[3]int64][]int)
+	} else {
+		w.exact = make(map[vector.V3]int)
+	}
+	return w
+}
+
+// add returns the index of a vertex within tol of v,
+// adding v as a new vertex if there isn't one.
+func (w *welder) add(v vector.V3) int {
+	if w.exact != nil {
+		i, ok := w.exact[v]
+		if !ok {
+			i = len(w.vertices)
+			w.exact[v] = i
+			w.vertices = append(w.vertices, v)
+		}
+		return i
+	}
+
+	c := w.cell(v)
+	for dx := int64(-1); dx <= 1; dx++ {
+		for dy := int64(-1); dy <= 1; dy++ {
+			for dz := int64(-1); dz <= 1; dz++ {
+				for _, i := range w.cells[[3]int64{c[0] + dx, c[1] + dy, c[2] + dz}] {
+					if w.vertices[i].Sub(v).Length() <= w.tol {
+						return i
+					}
+				}
+			}
+		}
+	}
+	i := len(w.vertices)
+	w.vertices = append(w.vertices, v)
+	w.cells[c] = append(w.cells[c], i)
+	return i
+}
+
+func (w *welder) cell(v vector.V3) [3]int64 {
+	return [3]int64{
+		int64(math.Floor(v.X / w.tol)),
+		int64(math.Floor(v.Y / w.tol)),
+		int64(math.Floor(v.Z / w.tol)),
+	}
+}
@@ -0,0 +1,87 @@
+package mesh
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"sigint.ca/slice/stl"
+	"sigint.ca/slice/vector"
+)
+
+func parseFile(t *testing.T, name string) *stl.Solid {
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	s, err := stl.Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestFromSolid(t *testing.T) {
+	cube := parseFile(t, "../testdata/cube20_ascii.stl")
+	m := FromSolid(cube, 0)
+	if len(m.Vertices) != 8 || len(m.Faces) != 12 {
+		t.Fatalf("got %d vertices and %d faces, want 8 and 12", len(m.Vertices), len(m.Faces))
+	}
+	edges := m.Edges()
+	if len(edges) != 18 {
+		t.Errorf("got %d edges, want 18", len(edges))
+	}
+	for _, e := range edges {
+		if n := len(m.EdgeFaces(e)); n != 2 {
+			t.Errorf("edge %v borders %d faces, want 2", e, n)
+		}
+		if len(m.EdgeFaces(Edge{e.B, e.A})) != 2 {
+			t.Errorf("edge %v not found with its vertices reversed", e)
+		}
+	}
+	for i := range m.Faces {
+		for j, n := range m.Neighbors(i) {
+			if n < 0 || n == i {
+				t.Errorf("face %d: bad neighbor %d across edge %d", i, n, j)
+			}
+		}
+	}
+
+	if s := m.Solid(); !reflect.DeepEqual(s.Facets, cube.Facets) || s.Name != cube.Name {
+		t.Errorf("round trip changed solid:\ngot  %v\nwant %v", s.Facets, cube.Facets)
+	}
+}
+
+func TestWeld(t *testing.T) {
+	cube := parseFile(t, "../testdata/cube20_ascii.stl")
+
+	// nudge each copy of each vertex by a different tiny amount
+	noisy := stl.NewSolid(append([]stl.Facet(nil), cube.Facets...))
+	for i := range noisy.Facets {
+		for j := range noisy.Facets[i].Vertices {
+			d := float64(3*i+j) * 1e-7
+			noisy.Facets[i].Vertices[j] = noisy.Facets[i].Vertices[j].Add(vector.V3{X: d, Y: -d, Z: d})
+		}
+	}
+
+	if m := FromSolid(noisy, 0); len(m.Vertices) != 36 {
+		t.Errorf("exact welding: got %d vertices, want 36", len(m.Vertices))
+	}
+	m := FromSolid(noisy, 1e-4)
+	if len(m.Vertices) != 8 {
+		t.Errorf("welding with tolerance: got %d vertices, want 8", len(m.Vertices))
+	}
+	for _, e := range m.Edges() {
+		if n := len(m.EdgeFaces(e)); n != 2 {
+			t.Errorf("edge %v borders %d faces, want 2", e, n)
+		}
+	}
+
+	// welding across a whole facet collapses it
+	tiny := stl.NewSolid([]stl.Facet{{Vertices: [3]vector.V3{{}, {X: 1e-5}, {Y: 1e-5}}}})
+	m = FromSolid(tiny, 1e-4)
+	if f := m.Faces[0]; f[0] != f[1] || f[1] != f[2] {
+		t.Errorf("got face %v, want all vertices welded", f)
+	}
+}
@@ -0,0 +1,163 @@
+package mesh
+
+import (
+	"fmt"
+
+	"sigint.ca/slice/stl"
+	"sigint.ca/slice/vector"
+)
+
+// A RepairConfig controls the repairs made by Repair.
+type RepairConfig struct {
+	// Tolerance is the distance within which vertices are welded.
+	Tolerance float64
+
+	// Holes bounded by at most MaxHoleEdges edges are closed. If it is
+	// zero, all holes are closed.
+	MaxHoleEdges int
+}
+
+// A RepairReport summarizes the changes made by Repair.
+type RepairReport struct {
+	Welded       int // vertices merged into a nearby vertex
+	Degenerate   int // degenerate facets removed
+	Duplicate    int // duplicate facets removed
+	Flipped      int // facets whose winding was reversed
+	HolesClosed  int
+	FacetsAdded  int // facets added to close holes
+	HolesSkipped int // holes left open because they were too big
+}
+
+func (r RepairReport) String() string {
+	return fmt.Sprintf("welded %d vertices, removed %d degenerate and %d duplicate facets, "+
+		"flipped %d facets, closed %d holes with %d facets, skipped %d holes",
+		r.Welded, r.Degenerate, r.Duplicate, r.Flipped, r.HolesClosed, r.FacetsAdded, r.HolesSkipped)
+}
+
+// Repair returns a repaired copy of s, along with a report of the changes.
+// Vertices are welded, degenerate and duplicate facets removed, facets
+// wound to face outwards, and holes closed by triangulating their edges.
+// Self-intersections and non-manifold edges are not repaired.
+func Repair(s *stl.Solid, cfg RepairConfig) (*stl.Solid, RepairReport) {
+	var r RepairReport
+
+	distinct := make(map[vector.V3]bool)
+	for _, f := range s.Facets {
+		for _, v := range f.Vertices {
+			distinct[v] = true
+		}
+	}
+	m := FromSolid(s, cfg.Tolerance)
+	r.Welded = len(distinct) - len(m.Vertices)
+
+	r.Degenerate, r.Duplicate = m.removeBadFaces()
+	faces := append([][3]int(nil), m.Faces...)
+	// holes are closed to match the winding of the faces around them, so
+	// make that consistent first. Which way out an open shell faces is
+	// only a guess, though, so orient it again once it is closed.
+	m.orient()
+	r.HolesClosed, r.FacetsAdded, r.HolesSkipped = m.closeHoles(cfg.MaxHoleEdges)
+	m.orient()
+	for i, f := range faces {
+		if m.Faces[i] != f {
+			r.Flipped++
+		}
+	}
+
+	return m.Solid(), r
+}
+
+// removeBadFaces removes degenerate and duplicate faces from m,
+// returning the number of each removed.
+func (m *Mesh) removeBadFaces() (degenerate, duplicate int) {
+	bad := make(map[int]bool)
+	for _, p := range m.checkFaces() {
+		switch p.Kind {
+		case DegenerateFacet:
+			bad[p.Facets[0]] = true
+			degenerate++
+		case DuplicateFacet:
+			bad[p.Facets[1]] = true
+			duplicate++
+		}
+	}
+	faces := m.Faces[:0]
+	for i, f := range m.Faces {
+		if !bad[i] {
+			faces = append(faces, f)
+		}
+	}
+	m.Faces = faces
+	m.Update()
+	return degenerate, duplicate
+}
+
+// orient reverses the winding of faces which disagree with the rest of
+// their shell, as determined by checkOrientation.
+func (m *Mesh) orient() {
+	for _, p := range m.checkOrientation() {
+		f := &m.Faces[p.Facets[0]]
+		f[1], f[2] = f[2], f[1]
+	}
+	m.Update()
+}
+
+// closeHoles triangulates the boundary loops of m with at most max edges,
+// or all of them if max is zero. It returns the number of holes closed,
+// the number of faces added, and the number of holes left open.
+func (m *Mesh) closeHoles(max int) (closed, added, skipped int) {
+	// boundary edges, directed to run around each hole in the opposite
+	// direction to the face beside it, so that patches match its winding
+	next := make(map[int][]int)
+	for _, e := range m.Edges() {
+		faces := m.edges[e]
+		if len(faces) != 1 {
+			continue
+		}
+		f := m.Faces[faces[0]]
+		for k := range f {
+			a, b := f[k], f[(k+1)%3]
+			if edge(a, b) == e {
+				next[b] = append(next[b], a)
+			}
+		}
+	}
+
+	for start := range m.Vertices {
+		for len(next[start]) > 0 {
+			loop := []int{start}
+			for v := start; ; {
+				n := next[v][0]
+				next[v] = next[v][1:]
+				if n == start {
+					break
+				}
+				loop = append(loop, n)
+				v = n
+				if len(next[v]) == 0 {
+					loop = nil // not a loop; give up on it
+					break
+				}
+			}
+			if loop == nil || len(loop) < 3 {
+				continue
+			}
+			if max > 0 && len(loop) > max {
+				skipped++
+				continue
+			}
+
+			poly := make([]vector.V3, len(loop))
+			for i, vi := range loop {
+				poly[i] = m.Vertices[vi]
+			}
+			for _, t := range stl.Triangulate(poly) {
+				m.Faces = append(m.Faces, [3]int{loop[t[0]], loop[t[1]], loop[t[2]]})
+				added++
+			}
+			closed++
+		}
+	}
+	m.Update()
+	return closed, added, skipped
+}
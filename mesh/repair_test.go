@@ -0,0 +1,76 @@
+package mesh
+
+import (
+	"testing"
+
+	"sigint.ca/slice/stl"
+	"sigint.ca/slice/vector"
+)
+
+func TestRepair(t *testing.T) {
+	cube := parseFile(t, "../testdata/cube20_ascii.stl")
+
+	// break the cube in every way that Repair can fix
+	broken := modified(cube, func(fs []stl.Facet) []stl.Facet {
+		fs[4].Vertices[0] = fs[4].Vertices[0].Add(vector.V3{X: 1e-6})
+		fs[7].Vertices[1], fs[7].Vertices[2] = fs[7].Vertices[2], fs[7].Vertices[1]
+		v, w := fs[0].Vertices[0], fs[0].Vertices[1]
+		fs = append(fs, fs[9], stl.Facet{Vertices: [3]vector.V3{v, w, v}})
+		return append(fs[:2], fs[4:]...) // a square hole
+	})
+	if len(Check(broken, 1e-4)) == 0 {
+		t.Fatal("broken cube has no problems")
+	}
+
+	s, r := Repair(broken, RepairConfig{Tolerance: 1e-4})
+	want := RepairReport{
+		Welded:      1,
+		Degenerate:  1,
+		Duplicate:   1,
+		Flipped:     1,
+		HolesClosed: 1,
+		FacetsAdded: 2,
+	}
+	if r != want {
+		t.Errorf("got report %v\nwant %v", r, want)
+	}
+	if ps := Check(s, 0); ps != nil {
+		t.Errorf("repaired cube has problems: %v", ps)
+	}
+	if len(s.Facets) != 12 {
+		t.Errorf("repaired cube has %d facets, want 12", len(s.Facets))
+	}
+	if s.Name != cube.Name {
+		t.Errorf("got name %q, want %q", s.Name, cube.Name)
+	}
+
+	// an inside-out cube is turned the right way out
+	inverted := modified(cube, func(fs []stl.Facet) []stl.Facet {
+		for i := range fs {
+			fs[i].Vertices[1], fs[i].Vertices[2] = fs[i].Vertices[2], fs[i].Vertices[1]
+		}
+		return fs
+	})
+	if _, r := Repair(inverted, RepairConfig{}); r.Flipped != 12 {
+		t.Errorf("inverted cube: flipped %d facets, want 12", r.Flipped)
+	}
+
+	// as is an open one, whose orientation can't be told until it's closed
+	open := modified(inverted, func(fs []stl.Facet) []stl.Facet { return fs[2:] })
+	s, r = Repair(open, RepairConfig{})
+	if r.Flipped != 10 || r.HolesClosed != 1 {
+		t.Errorf("open inverted cube: got report %v, want 10 flipped and 1 hole closed", r)
+	}
+	if v := s.Volume(); v <= 0 {
+		t.Errorf("open inverted cube: repaired volume %v, want positive", v)
+	}
+	if ps := Check(s, 0); ps != nil {
+		t.Errorf("open inverted cube: repaired cube has problems: %v", ps)
+	}
+
+	// big holes can be left alone
+	if _, r := Repair(modified(cube, func(fs []stl.Facet) []stl.Facet { return fs[2:] }),
+		RepairConfig{MaxHoleEdges: 3}); r.HolesSkipped != 1 || r.HolesClosed != 0 {
+		t.Errorf("got report %v, want one hole skipped", r)
+	}
+}
@@ -0,0 +1,134 @@
+package mesh
+
+import (
+	"math"
+	"sort"
+
+	"sigint.ca/slice/stl"
+	"sigint.ca/slice/vector"
+)
+
+// Shells returns the connected components of m: groups of faces joined by
+// shared edges, including non-manifold ones. A hollow part has a shell for
+// its outside and another for each cavity; see Bodies. Shells are ordered by their
+// lowest face index, and their faces by index. Collapsed faces, which
+// have no edges, are left out.
+func (m *Mesh) Shells() [][]int {
+	shell := make([]int, len(m.Faces)) // 1-based shell of each face
+	var n int
+	var queue []int
+	for start, f := range m.Faces {
+		if shell[start] != 0 || collapsed(f) {
+			continue
+		}
+		n++
+		shell[start] = n
+		queue = append(queue[:0], start)
+		for len(queue) > 0 {
+			f := queue[len(queue)-1]
+			queue = queue[:len(queue)-1]
+			for _, e := range m.FaceEdges(f) {
+				for _, g := range m.edges[e] {
+					if shell[g] == 0 {
+						shell[g] = n
+						queue = append(queue, g)
+					}
+				}
+			}
+		}
+	}
+
+	shells := make([][]int, n)
+	for f, id := range shell {
+		if id != 0 {
+			shells[id-1] = append(shells[id-1], f)
+		}
+	}
+	return shells
+}
+
+// Bodies groups the shells of m into bodies. A shell enclosing negative
+// volume (one wound inside out) which lies within another shell is taken
+// to be a cavity in the smallest such shell, and joins its body. Other
+// shells are bodies by themselves. Bodies are ordered by their lowest
+// face index, and their faces by index.
+func (m *Mesh) Bodies() [][]int {
+	shells := m.Shells()
+	volumes := make([]float64, len(shells))
+	for i, faces := range shells {
+		for _, f := range faces {
+			volumes[i] += m.signedVolume(f)
+		}
+	}
+
+	body := make([]int, len(shells)) // shell whose body each shell joins
+	for i, faces := range shells {
+		body[i] = i
+		if volumes[i] >= 0 {
+			continue
+		}
+		p := m.Vertices[m.Faces[faces[0]][0]]
+		for j, outer := range shells {
+			if volumes[j] > 0 && -volumes[i] < volumes[j] && m.inside(p, outer) &&
+				(body[i] == i || volumes[j] < volumes[body[i]]) {
+				body[i] = j
+			}
+		}
+	}
+
+	var bodies [][]int
+	index := make(map[int]int) // of each outer shell's body in bodies
+	for i, faces := range shells {
+		j, ok := index[body[i]]
+		if !ok {
+			j = len(bodies)
+			index[body[i]] = j
+			bodies = append(bodies, nil)
+		}
+		bodies[j] = append(bodies[j], faces...)
+	}
+	for _, faces := range bodies {
+		sort.Ints(faces)
+	}
+	return bodies
+}
+
+// inside reports whether p lies inside the closed shell made up of faces,
+// by counting the faces crossed by a ray from p to beyond the mesh.
+func (m *Mesh) inside(p vector.V3, faces []int) bool {
+	min, max := m.Vertices[0], m.Vertices[0]
+	for _, v := range m.Vertices {
+		min = vector.V3{X: math.Min(min.X, v.X), Y: math.Min(min.Y, v.Y), Z: math.Min(min.Z, v.Z)}
+		max = vector.V3{X: math.Max(max.X, v.X), Y: math.Max(max.Y, v.Y), Z: math.Max(max.Z, v.Z)}
+	}
+	// an odd direction, to make passing through edges and vertices unlikely
+	dir := vector.V3{X: 1, Y: 0.3183, Z: 0.2718}.Normalize()
+	q := p.Add(dir.Mul(2*max.Sub(min).Length() + 1))
+	var n int
+	for _, f := range faces {
+		if _, ok := m.segmentHits(p, q, f); ok {
+			n++
+		}
+	}
+	return n%2 == 1
+}
+
+// Split welds the vertices of s which are within tol of each other, and
+// returns a solid for each of the resulting bodies, in the order given by
+// Bodies: internal cavities stay with the part enclosing them. The facets
+// of each are copied unchanged from s, and each has the name and header
+// of s. Facets which collapse when welded are dropped.
+func Split(s *stl.Solid, tol float64) []*stl.Solid {
+	bodies := FromSolid(s, tol).Bodies()
+	solids := make([]*stl.Solid, len(bodies))
+	for i, faces := range bodies {
+		facets := make([]stl.Facet, len(faces))
+		for j, f := range faces {
+			facets[j] = s.Facets[f]
+		}
+		solids[i] = stl.NewSolid(facets)
+		solids[i].Name = s.Name
+		solids[i].Header = s.Header
+	}
+	return solids
+}
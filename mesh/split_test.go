@@ -0,0 +1,99 @@
+package mesh
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"sigint.ca/slice/stl"
+	"sigint.ca/slice/vector"
+)
+
+func TestSplit(t *testing.T) {
+	cube := parseFile(t, "../testdata/cube20_ascii.stl")
+	if solids := Split(cube, 0); len(solids) != 1 || !reflect.DeepEqual(solids[0].Facets, cube.Facets) {
+		t.Fatalf("split a single cube into %d solids", len(solids))
+	}
+
+	// two cubes, interleaved, and a third which only shares a corner with
+	// the first, and so isn't joined to it
+	a := cube.Facets
+	b := moved(a, vector.V3{X: 30})
+	c := moved(a, vector.V3{X: -20, Y: -20, Z: -20})
+	var facets []stl.Facet
+	for i := range a {
+		facets = append(facets, a[i], b[i])
+	}
+	facets = append(facets, c...)
+	s := stl.NewSolid(facets)
+	s.Name = "cubes"
+
+	solids := Split(s, 0)
+	if len(solids) != 3 {
+		t.Fatalf("got %d solids, want 3", len(solids))
+	}
+	for i, want := range [][]stl.Facet{a, b, c} {
+		got := solids[i]
+		if got.Name != "cubes" || len(got.Facets) != 12 {
+			t.Errorf("solid %d: got %q with %d facets", i, got.Name, len(got.Facets))
+			continue
+		}
+		wantMin, wantMax := stl.NewSolid(want).Bounds()
+		if min, max := got.Bounds(); min != wantMin || max != wantMax {
+			t.Errorf("solid %d: got bounds %v-%v, want %v-%v", i, min, max, wantMin, wantMax)
+		}
+		if ps := Check(got, 0); ps != nil {
+			t.Errorf("solid %d: %v", i, ps.Err())
+		}
+	}
+
+	// a cube almost sharing a side with the first is joined to it by welding
+	d := moved(a, vector.V3{X: -20 + 1e-5})
+	s = stl.NewSolid(append(facets[:24:24], d...))
+	if n := len(Split(s, 0)); n != 3 {
+		t.Errorf("without welding: got %d solids, want 3", n)
+	}
+	if n := len(Split(s, 1e-4)); n != 2 {
+		t.Errorf("with welding: got %d solids, want 2", n)
+	}
+}
+
+func TestSplitHollow(t *testing.T) {
+	cube := parseFile(t, "../testdata/cube20_ascii.stl")
+	min, max := cube.Bounds()
+
+	// a cube with a void in the middle, wound inward, and a solid cube
+	// beside it
+	void := modified(cube, func(fs []stl.Facet) []stl.Facet {
+		for i := range fs {
+			fs[i].Vertices[1], fs[i].Vertices[2] = fs[i].Vertices[2], fs[i].Vertices[1]
+		}
+		return fs
+	})
+	void.Scale(min.Add(max).Mul(0.5), vector.V3{X: 0.5, Y: 0.5, Z: 0.5})
+	var facets []stl.Facet
+	facets = append(facets, cube.Facets...)
+	facets = append(facets, void.Facets...)
+	facets = append(facets, moved(cube.Facets, vector.V3{X: 30})...)
+	s := stl.NewSolid(facets)
+
+	if n := len(FromSolid(s, 0).Shells()); n != 3 {
+		t.Fatalf("got %d shells, want 3", n)
+	}
+	solids := Split(s, 0)
+	if len(solids) != 2 {
+		t.Fatalf("got %d solids, want 2", len(solids))
+	}
+	if !reflect.DeepEqual(solids[0].Facets, facets[:24]) {
+		t.Errorf("hollow cube wasn't kept together with its void")
+	}
+	if got, want := solids[0].Volume(), cube.Volume()*7/8; math.Abs(got-want) > 1e-6*want {
+		t.Errorf("hollow cube: got volume %v, want %v", got, want)
+	}
+
+	// an inside-out shell which isn't within another is left by itself
+	s = stl.NewSolid(append(facets[:12:12], moved(void.Facets, vector.V3{X: 30})...))
+	if n := len(Split(s, 0)); n != 2 {
+		t.Errorf("got %d solids, want 2", n)
+	}
+}
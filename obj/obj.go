@@ -0,0 +1,175 @@
+// Package obj parses Wavefront OBJ files into solids.
+package obj
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"sigint.ca/slice/stl"
+	"sigint.ca/slice/vector"
+)
+
+// Parse parses an OBJ file from r, returning a solid for each object or
+// group ("o" or "g" statement) which has faces. Faces which come before the
+// first object or group belong to a solid with an empty name. Polygonal
+// faces are triangulated, and facet normals follow the vertex winding,
+// reconciled with any vertex normals the file provides.
+//
+// Only geometry is read; materials, texture coordinates, lines and
+// free-form surfaces are ignored.
+func Parse(r io.Reader) ([]*stl.Solid, error) {
+	p := &parser{
+		current: &group{},
+	}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	var lineno int
+	var continued string
+	for sc.Scan() {
+		lineno++
+		line := continued + sc.Text()
+		if strings.HasSuffix(line, "\\") {
+			continued = strings.TrimSuffix(line, "\\") + " "
+			continue
+		}
+		continued = ""
+		if err := p.parseLine(line); err != nil {
+			return nil, fmt.Errorf("error decoding OBJ: line %d: %v", lineno, err)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("error decoding OBJ: %v", err)
+	}
+	p.endGroup()
+
+	solids := make([]*stl.Solid, 0, len(p.groups))
+	for _, g := range p.groups {
+		s := stl.NewSolid(g.facets)
+		s.Name = g.name
+		s.ReconcileNormals(stl.Repair)
+		solids = append(solids, s)
+	}
+	return solids, nil
+}
+
+type group struct {
+	name   string
+	facets []stl.Facet
+}
+
+type parser struct {
+	vertices []vector.V3
+	normals  []vector.V3
+	current  *group
+	groups   []*group
+}
+
+func (p *parser) parseLine(line string) error {
+	if i := strings.IndexByte(line, '#'); i >= 0 {
+		line = line[:i]
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	switch fields[0] {
+	case "v":
+		v, err := parseV3(fields[1:])
+		if err != nil {
+			return fmt.Errorf("vertex: %v", err)
+		}
+		p.vertices = append(p.vertices, v)
+	case "vn":
+		n, err := parseV3(fields[1:])
+		if err != nil {
+			return fmt.Errorf("normal: %v", err)
+		}
+		p.normals = append(p.normals, n)
+	case "f":
+		if err := p.parseFace(fields[1:]); err != nil {
+			return fmt.Errorf("face: %v", err)
+		}
+	case "o", "g":
+		p.endGroup()
+		p.current = &group{name: strings.Join(fields[1:], " ")}
+	}
+	return nil
+}
+
+// endGroup finishes the current group, keeping it if it has any faces.
+func (p *parser) endGroup() {
+	if len(p.current.facets) > 0 {
+		p.groups = append(p.groups, p.current)
+	}
+	p.current = &group{}
+}
+
+// parseFace parses the vertex references of a face,
+// of the form v, v/vt, v//vn or v/vt/vn.
+func (p *parser) parseFace(refs []string) error {
+	if len(refs) < 3 {
+		return fmt.Errorf("need at least 3 vertices, got %d", len(refs))
+	}
+	poly := make([]vector.V3, len(refs))
+	var normal vector.V3
+	for i, ref := range refs {
+		parts := strings.Split(ref, "/")
+		vi, err := index(parts[0], len(p.vertices))
+		if err != nil {
+			return fmt.Errorf("vertex %q: %v", ref, err)
+		}
+		poly[i] = p.vertices[vi]
+		if len(parts) == 3 && parts[2] != "" {
+			ni, err := index(parts[2], len(p.normals))
+			if err != nil {
+				return fmt.Errorf("normal %q: %v", ref, err)
+			}
+			normal = normal.Add(p.normals[ni])
+		}
+	}
+
+	for _, t := range stl.Triangulate(poly) {
+		p.current.facets = append(p.current.facets, stl.Facet{
+			Vertices: [3]vector.V3{poly[t[0]], poly[t[1]], poly[t[2]]},
+			Normal:   normal,
+		})
+	}
+	return nil
+}
+
+// index converts a 1-based OBJ index, which may be negative to count back
+// from the most recent element, into an index into a list of length n.
+func index(s string, n int) (int, error) {
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+	if i < 0 {
+		i += n
+	} else {
+		i--
+	}
+	if i < 0 || i >= n {
+		return 0, fmt.Errorf("index out of range")
+	}
+	return i, nil
+}
+
+func parseV3(fields []string) (vector.V3, error) {
+	if len(fields) < 3 {
+		return vector.V3{}, fmt.Errorf("need 3 coordinates, got %d", len(fields))
+	}
+	var xyz [3]float64
+	for i := range xyz {
+		f, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return vector.V3{}, err
+		}
+		xyz[i] = f
+	}
+	return vector.V3{X: xyz[0], Y: xyz[1], Z: xyz[2]}, nil
+}
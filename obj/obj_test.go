@@ -0,0 +1,104 @@
+package obj
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"sigint.ca/slice/stl"
+)
+
+const cube = `# a 20mm cube made of quads
+v 0 0 0
+v 20 0 0
+v 20 20 0
+v 0 20 0
+v 0 0 20
+v 20 0 20
+v 20 20 20
+v 0 20 20
+vn 0 0 -1
+vn 0 0 1
+o cube
+f 1//1 4//1 3//1 2//1
+f 5//2 6//2 7//2 8//2
+f 1 2 6 5
+f 2 3 7 6
+f 3/1 4/1 8/1 7/1
+f 4 1 5 8
+`
+
+// an L-shaped (concave) hexagon in the x-z plane, defined
+// with negative indices, in a group of its own
+const ell = `
+g ell
+v 0 0 0
+v 0 0 2
+v 1 0 2
+v 1 0 1
+v 2 0 1
+v 2 0 0
+f -6 -5 -4 -3 -2 -1
+`
+
+func area(f stl.Facet) float64 {
+	v := f.Vertices[1].Sub(f.Vertices[0])
+	w := f.Vertices[2].Sub(f.Vertices[0])
+	return v.Cross(w).Length() / 2
+}
+
+func TestParse(t *testing.T) {
+	solids, err := Parse(strings.NewReader(cube + ell))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(solids) != 2 {
+		t.Fatalf("got %d solids, want 2", len(solids))
+	}
+
+	c := solids[0]
+	if c.Name != "cube" || len(c.Facets) != 12 {
+		t.Errorf("cube: name=%q, %d facets", c.Name, len(c.Facets))
+	}
+	min, max := c.Bounds()
+	if min.String() != "(0.0, 0.0, 0.0)" || max.String() != "(20.0, 20.0, 20.0)" {
+		t.Errorf("cube: bounds=%v-%v", min, max)
+	}
+	if len(c.NormalMismatches) != 0 {
+		t.Errorf("cube: mismatched normals %v", c.NormalMismatches)
+	}
+	// all normals should point outwards
+	for i, f := range c.Facets {
+		center := f.Vertices[0].Add(f.Vertices[1]).Add(f.Vertices[2]).Mul(1.0 / 3)
+		out := center.Sub(max.Mul(0.5))
+		if f.Normal.Dot(out) <= 0 {
+			t.Errorf("cube: facet %d: normal %v points inwards", i, f.Normal)
+		}
+	}
+
+	l := solids[1]
+	if l.Name != "ell" || len(l.Facets) != 4 {
+		t.Errorf("ell: name=%q, %d facets", l.Name, len(l.Facets))
+	}
+	var total float64
+	for _, f := range l.Facets {
+		total += area(f)
+	}
+	if math.Abs(total-3) > 1e-9 {
+		t.Errorf("ell: area=%f, want 3", total)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"v 0 0 0\nv 1 0 0\nf 1 2 3\n",
+		"v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2\n",
+		"v 0 0\n",
+		"v 0 0 0\nv 1 0 0\nv 0 1 0\nf -4 -2 -1\n",
+	}
+	for _, input := range tests {
+		if _, err := Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("%q: no error", input)
+		}
+	}
+}
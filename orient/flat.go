@@ -0,0 +1,103 @@
+package orient
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"sigint.ca/slice/stl"
+	"sigint.ca/slice/vector"
+)
+
+// flatTolerance is how far, in mm, the rest of a solid may reach
+// below a face which is laid flat, to allow for rounding error.
+const flatTolerance = 1e-4
+
+// LayFlat rotates s so that the given facet lies flat on the bed, facing
+// down, with the lowest point of s at Z=0. The rotation is about the
+// middle of s, so it stays roughly where it was horizontally. It returns
+// an error, leaving s alone, if the facet is degenerate or other parts
+// of s would reach below it.
+func LayFlat(s *stl.Solid, facet int) error {
+	if facet < 0 || facet >= len(s.Facets) {
+		return fmt.Errorf("no facet %d", facet)
+	}
+	n, a := normalArea(s.Facets[facet])
+	if a == 0 {
+		return fmt.Errorf("facet %d is degenerate", facet)
+	}
+	r := rotationToDown(n)
+	if !canRest(s, r, s.Facets[facet].Vertices[0]) {
+		return fmt.Errorf("facet %d can't lie flat: the solid reaches below it", facet)
+	}
+
+	min, max := s.Bounds()
+	mid := min.Add(max).Mul(0.5)
+	s.Transform(vector.Translation(mid).Mul(r).Mul(vector.Translation(mid.Mul(-1))))
+	s.PlaceOnBed()
+	return nil
+}
+
+// canRest reports whether, when rotated by r, no vertex of s is
+// below v by more than flatTolerance.
+func canRest(s *stl.Solid, r vector.M4, v vector.V3) bool {
+	z := r.Apply(v).Z
+	for _, f := range s.Facets {
+		for _, u := range f.Vertices {
+			if r.Apply(u).Z < z-flatTolerance {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// LargestFace returns the facets of the largest flat face on which s can
+// rest: that is, the coplanar facets with the largest total area which
+// face outwards from the convex hull of s. It returns nil if s is empty.
+// Any of the facets can be passed to LayFlat.
+func LargestFace(s *stl.Solid) []int {
+	type plane struct {
+		facets []int
+		normal vector.V3
+		area   float64
+	}
+	planes := make(map[[4]int64]*plane)
+	for i, f := range s.Facets {
+		n, a := normalArea(f)
+		if a == 0 {
+			continue
+		}
+		d := n.Dot(f.Vertices[0])
+		key := [4]int64{
+			int64(math.Round(n.X * 1e4)),
+			int64(math.Round(n.Y * 1e4)),
+			int64(math.Round(n.Z * 1e4)),
+			int64(math.Round(d / flatTolerance)),
+		}
+		p, ok := planes[key]
+		if !ok {
+			p = &plane{normal: n}
+			planes[key] = p
+		}
+		p.facets = append(p.facets, i)
+		p.area += a
+	}
+
+	sorted := make([]*plane, 0, len(planes))
+	for _, p := range planes {
+		sorted = append(sorted, p)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].area != sorted[j].area {
+			return sorted[i].area > sorted[j].area
+		}
+		return sorted[i].facets[0] < sorted[j].facets[0]
+	})
+	for _, p := range sorted {
+		if canRest(s, rotationToDown(p.normal), s.Facets[p.facets[0]].Vertices[0]) {
+			return p.facets
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package orient
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"sigint.ca/slice/stl"
+	"sigint.ca/slice/vector"
+)
+
+// an L-shaped extrusion: a tall box with a short, long foot, where the
+// biggest face is the back of the upright and the underside of the foot
+// together, and the inside corner faces can't be rested on
+func ell() *stl.Solid {
+	upright := box(vector.V3{X: 10, Y: 20, Z: 40})
+	foot := box(vector.V3{X: 50, Y: 20, Z: 10})
+	facets := append(upright.Facets, foot.Facets...)
+	s := stl.NewSolid(facets)
+	s.ReconcileNormals(stl.TrustWinding)
+	return s
+}
+
+func TestLayFlat(t *testing.T) {
+	s := ell()
+	face := LargestFace(s)
+	// the undersides of the upright and the foot, 10x20 and 50x20
+	if want := []int{0, 1, 12, 13}; !reflect.DeepEqual(face, want) {
+		t.Errorf("got largest face %v, want %v", face, want)
+	}
+
+	// the inner face of the upright is blocked by the foot
+	before := append([]stl.Facet(nil), s.Facets...)
+	if err := LayFlat(s, 7); err == nil {
+		t.Error("no error laying on the inside of the corner")
+	}
+	if !reflect.DeepEqual(s.Facets, before) {
+		t.Error("solid changed by failed LayFlat")
+	}
+
+	// lay it on the outside of the upright
+	if err := LayFlat(s, 10); err != nil {
+		t.Fatal(err)
+	}
+	min, max := s.Bounds()
+	if math.Abs(min.Z) > 1e-9 || math.Abs(max.Z-50) > 1e-9 {
+		t.Errorf("got bounds %v-%v, want height 50", min, max)
+	}
+	for _, v := range s.Facets[10].Vertices {
+		if math.Abs(v.Z) > 1e-9 {
+			t.Errorf("facet 10 vertex %v is not on the bed", v)
+		}
+	}
+	if n := s.Facets[10].Normal; !near(n, vector.V3{Z: -1}) {
+		t.Errorf("facet 10 has normal %v, want down", n)
+	}
+
+	if err := LayFlat(s, 99); err == nil {
+		t.Error("no error for bad facet index")
+	}
+}
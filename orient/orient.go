@@ -0,0 +1,194 @@
+// Package orient chooses how to rotate a solid for printing.
+package orient
+
+import (
+	"math"
+	"sort"
+
+	"sigint.ca/slice/stl"
+	"sigint.ca/slice/vector"
+)
+
+// Weights set the relative importance of the terms of a candidate's score.
+// The terms have different units, so the weights also convert between them.
+type Weights struct {
+	Overhang float64 // per mm² of overhanging surface
+	Support  float64 // per mm³ of support material
+	Height   float64 // per mm of print height
+}
+
+// A Config controls the candidate orientations and how they are scored.
+type Config struct {
+	Weights Weights
+
+	// OverhangAngle is the largest angle from vertical, in radians, at which
+	// downward facing surfaces can be printed without support.
+	OverhangAngle float64
+
+	// MaxCandidates limits the number of orientations considered, beyond
+	// the six which rest the solid's bounding box on each of its faces.
+	// The others rest the solid on its largest flat areas.
+	MaxCandidates int
+}
+
+// DefaultConfig is a reasonable configuration for FDM printing,
+// which favours avoiding support over reducing height.
+var DefaultConfig = Config{
+	Weights:       Weights{Overhang: 1, Support: 0.1, Height: 1},
+	OverhangAngle: math.Pi / 4,
+	MaxCandidates: 24,
+}
+
+// A Candidate is an orientation of a solid, along with its score.
+type Candidate struct {
+	Down     vector.V3 // direction in the original solid which is turned to face down
+	Rotation vector.M4 // rotation about the origin which turns Down to face -Z
+
+	Overhang float64 // area of surfaces needing support, in mm²
+	Support  float64 // approximate volume of support, in mm³
+	Height   float64 // print height, in mm
+	Score    float64 // weighted sum of the above; lower is better
+}
+
+// Optimize scores candidate orientations of s according to cfg,
+// and returns them in order of score, best first. To use the best,
+// apply its Rotation to s, then place s on the bed.
+func Optimize(s *stl.Solid, cfg Config) []Candidate {
+	downs := candidates(s, cfg.MaxCandidates)
+	cs := make([]Candidate, len(downs))
+	for i, d := range downs {
+		cs[i] = evaluate(s, d, cfg)
+	}
+	sort.SliceStable(cs, func(i, j int) bool { return cs[i].Score < cs[j].Score })
+	return cs
+}
+
+// candidates returns the directions to try facing down: the six axis
+// directions, followed by the normals of the largest flat areas of s.
+func candidates(s *stl.Solid, max int) []vector.V3 {
+	downs := []vector.V3{
+		{Z: -1}, {Z: 1}, {X: -1}, {X: 1}, {Y: -1}, {Y: 1},
+	}
+
+	// total the area facing in each direction, to within a degree or so
+	type group struct {
+		normal vector.V3
+		area   float64
+	}
+	groups := make(map[[3]int]*group)
+	for _, f := range s.Facets {
+		n, a := normalArea(f)
+		if a == 0 {
+			continue
+		}
+		key := [3]int{int(math.Round(n.X * 50)), int(math.Round(n.Y * 50)), int(math.Round(n.Z * 50))}
+		g, ok := groups[key]
+		if !ok {
+			g = &group{}
+			groups[key] = g
+		}
+		g.normal = g.normal.Add(n.Mul(a))
+		g.area += a
+	}
+	sorted := make([]*group, 0, len(groups))
+	for _, g := range groups {
+		sorted = append(sorted, g)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].area != sorted[j].area {
+			return sorted[i].area > sorted[j].area
+		}
+		return less(sorted[i].normal, sorted[j].normal)
+	})
+
+outer:
+	for _, g := range sorted {
+		if len(downs) >= 6+max {
+			break
+		}
+		n := g.normal.Normalize()
+		for _, d := range downs {
+			if n.Dot(d) > math.Cos(math.Pi/180) {
+				continue outer
+			}
+		}
+		downs = append(downs, n)
+	}
+	return downs
+}
+
+// less orders vectors, so that ties are broken deterministically.
+func less(a, b vector.V3) bool {
+	if a.X != b.X {
+		return a.X < b.X
+	}
+	if a.Y != b.Y {
+		return a.Y < b.Y
+	}
+	return a.Z < b.Z
+}
+
+// normalArea returns the unit normal and area of f, according to its winding.
+func normalArea(f stl.Facet) (vector.V3, float64) {
+	c := f.Vertices[1].Sub(f.Vertices[0]).Cross(f.Vertices[2].Sub(f.Vertices[0]))
+	l := c.Length()
+	if !(l > 1e-12) {
+		return vector.V3{}, 0
+	}
+	return c.Mul(1 / l), l / 2
+}
+
+// rotationToDown returns a rotation which turns d to face -Z.
+func rotationToDown(d vector.V3) vector.M4 {
+	down := vector.V3{Z: -1}
+	axis := d.Cross(down)
+	cos := d.Dot(down)
+	if axis.Length() < 1e-9 {
+		if cos > 0 {
+			return vector.Identity()
+		}
+		return vector.Rotation(vector.V3{X: 1}, math.Pi)
+	}
+	return vector.Rotation(axis, math.Atan2(axis.Length(), cos))
+}
+
+// evaluate scores the orientation of s which turns d to face down.
+func evaluate(s *stl.Solid, d vector.V3, cfg Config) Candidate {
+	c := Candidate{Down: d, Rotation: rotationToDown(d)}
+	r := c.Rotation
+
+	minz, maxz := math.Inf(+1), math.Inf(-1)
+	for _, f := range s.Facets {
+		for _, v := range f.Vertices {
+			z := r.Apply(v).Z
+			minz = math.Min(minz, z)
+			maxz = math.Max(maxz, z)
+		}
+	}
+	if len(s.Facets) > 0 {
+		c.Height = maxz - minz
+	}
+
+	limit := math.Sin(cfg.OverhangAngle)
+	for _, f := range s.Facets {
+		n, a := normalArea(f)
+		nz := r.ApplyDir(n).Z
+		if a == 0 || -nz <= limit+1e-9 {
+			continue
+		}
+		var z [3]float64
+		for i, v := range f.Vertices {
+			z[i] = r.Apply(v).Z - minz
+		}
+		if z[0] < 1e-6 && z[1] < 1e-6 && z[2] < 1e-6 {
+			continue // resting on the bed
+		}
+		c.Overhang += a
+		// the column of support beneath the facet
+		c.Support += a * -nz * (z[0] + z[1] + z[2]) / 3
+	}
+
+	w := cfg.Weights
+	c.Score = w.Overhang*c.Overhang + w.Support*c.Support + w.Height*c.Height
+	return c
+}
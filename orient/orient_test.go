@@ -0,0 +1,92 @@
+package orient
+
+import (
+	"math"
+	"testing"
+
+	"sigint.ca/slice/stl"
+	"sigint.ca/slice/vector"
+)
+
+// box returns a box with outward wound facets.
+func box(size vector.V3) *stl.Solid {
+	v := func(x, y, z float64) vector.V3 { return vector.V3{X: x * size.X, Y: y * size.Y, Z: z * size.Z} }
+	quads := [][4]vector.V3{
+		{v(0, 0, 0), v(0, 1, 0), v(1, 1, 0), v(1, 0, 0)},
+		{v(0, 0, 1), v(1, 0, 1), v(1, 1, 1), v(0, 1, 1)},
+		{v(0, 0, 0), v(1, 0, 0), v(1, 0, 1), v(0, 0, 1)},
+		{v(1, 0, 0), v(1, 1, 0), v(1, 1, 1), v(1, 0, 1)},
+		{v(1, 1, 0), v(0, 1, 0), v(0, 1, 1), v(1, 1, 1)},
+		{v(0, 1, 0), v(0, 0, 0), v(0, 0, 1), v(0, 1, 1)},
+	}
+	var facets []stl.Facet
+	for _, q := range quads {
+		facets = append(facets,
+			stl.Facet{Vertices: [3]vector.V3{q[0], q[1], q[2]}},
+			stl.Facet{Vertices: [3]vector.V3{q[0], q[2], q[3]}})
+	}
+	s := stl.NewSolid(facets)
+	s.ReconcileNormals(stl.TrustWinding)
+	return s
+}
+
+// pyramid returns a square pyramid with a 20mm base, 5mm high.
+func pyramid() *stl.Solid {
+	a, b, c, d := vector.V3{}, vector.V3{X: 20}, vector.V3{X: 20, Y: 20}, vector.V3{Y: 20}
+	apex := vector.V3{X: 10, Y: 10, Z: 5}
+	s := stl.NewSolid([]stl.Facet{
+		{Vertices: [3]vector.V3{a, c, b}},
+		{Vertices: [3]vector.V3{a, d, c}},
+		{Vertices: [3]vector.V3{a, b, apex}},
+		{Vertices: [3]vector.V3{b, c, apex}},
+		{Vertices: [3]vector.V3{c, d, apex}},
+		{Vertices: [3]vector.V3{d, a, apex}},
+	})
+	s.ReconcileNormals(stl.TrustWinding)
+	return s
+}
+
+func near(a, b vector.V3) bool {
+	return a.Sub(b).Length() < 1e-9
+}
+
+func TestOptimize(t *testing.T) {
+	cs := Optimize(pyramid(), DefaultConfig)
+	best := cs[0]
+	if !near(best.Down, vector.V3{Z: -1}) || best.Overhang != 0 || best.Support != 0 || math.Abs(best.Height-5) > 1e-9 {
+		t.Errorf("pyramid: got best candidate %+v, want it resting on its base", best)
+	}
+	for _, c := range cs {
+		if !near(c.Down, vector.V3{Z: 1}) {
+			continue
+		}
+		// upside down, the four sides overhang: 4 * 20 * hypot(10, 5) / 2
+		if want := 40 * math.Hypot(10, 5); math.Abs(c.Overhang-want) > 1e-9 {
+			t.Errorf("pyramid upside down: got overhang %v, want %v", c.Overhang, want)
+		}
+		if c.Support <= 0 {
+			t.Errorf("pyramid upside down: got support volume %v", c.Support)
+		}
+	}
+	for i := 1; i < len(cs); i++ {
+		if cs[i].Score < cs[i-1].Score {
+			t.Errorf("candidates out of order: %v before %v", cs[i-1].Score, cs[i].Score)
+		}
+	}
+	// a candidate for each side, to within a degree
+	if len(cs) != 6+4 {
+		t.Errorf("got %d candidates, want 10", len(cs))
+	}
+
+	// a post is laid down to make it shorter
+	post := box(vector.V3{X: 10, Y: 10, Z: 50})
+	best = Optimize(post, DefaultConfig)[0]
+	if math.Abs(best.Height-10) > 1e-9 || best.Overhang != 0 {
+		t.Errorf("post: got best candidate %+v, want it lying down", best)
+	}
+	post.Transform(best.Rotation)
+	if min, max := post.Bounds(); math.Abs(max.Z-min.Z-10) > 1e-9 {
+		t.Errorf("post: got bounds %v-%v after rotation", min, max)
+	}
+
+}
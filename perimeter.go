@@ -6,57 +6,74 @@ package slice
 import (
 	"container/list"
 	"fmt"
+	"log/slog"
 	"math"
 
 	"sigint.ca/slice/stl"
 	"sigint.ca/slice/vector"
 )
 
-func sliceLayer(n int, z float64, s *stl.Solid, cfg Config) *Layer {
-	dprintf("slicing layer %d...", n)
+// sliceLayer slices s at layer n. Facets marked in skip, which are
+// degenerate and already reported as such, are left out.
+func sliceLayer(lg logger, n int, sp span, s *stl.Solid, skip []bool, cfg Config) *Layer {
+	lg = lg.at(stageSlice)
+	lg.debugf("slicing layer %d...", n)
+	z := sp.plane()
 	// find the facets which intersect this layer
-	facets := make([]stl.Facet, 0)
-	for _, f := range s.Facets {
-		minz, maxz := math.Inf(+1), math.Inf(-1)
-		for _, v := range f.Vertices {
-			minz = math.Min(minz, v.Z)
-			maxz = math.Max(maxz, v.Z)
+	facets := make([]int, 0)
+	for i, f := range s.Facets {
+		if skip[i] {
+			continue
 		}
-		if minz <= z && maxz >= z {
-			facets = append(facets, f)
+		if minz, maxz := zRange(f); minz <= z && maxz >= z {
+			facets = append(facets, i)
 		}
 	}
 
 	// first, slice all the facets
 	segments := make([]*Segment, 0, len(facets))
-	for _, f := range facets {
+	for _, i := range facets {
+		f := s.Facets[i]
 		s := sliceFacet(f, z)
 		if s == nil {
-			dprintf("discarding nil Segment")
+			// the entire facet coincides with the plane.
+			// no need to keep any Segment; other facets
+			// should be sufficient to draw the perimeter
+			lg.report(Diagnostic{
+				Kind:     CoincidentFacet,
+				Location: Vertex2{f.Vertices[0].X, f.Vertices[0].Y},
+				Facet:    i,
+			})
 		} else if s.From.touches(s.To) {
-			dprintf("discarding tiny Segment")
+			lg.debugf("discarding tiny Segment")
 		} else {
 			segments = append(segments, s)
 		}
 	}
-	dprintf("sliced %d segments", len(segments))
+	lg.debugf("sliced %d segments", len(segments))
 
 	if len(segments) == 0 {
-		wprintf("no segments, returning empty layer")
+		lg.report(Diagnostic{Kind: EmptyLayer, Facet: -1, Msg: "no segments, returning empty layer"})
 		return &Layer{
-			n:   n,
-			z:   z,
-			stl: s,
+			n:      n,
+			z:      sp.top(),
+			height: sp.height,
+			width:  cfg.lineWidth(n),
+			speed:  cfg.speed(n),
+			stl:    s,
 		}
 	}
 
 	l := &Layer{
-		n:   n,
-		z:   z,
-		stl: s,
+		n:      n,
+		z:      sp.top(),
+		height: sp.height,
+		width:  cfg.lineWidth(n),
+		speed:  cfg.speed(n),
+		stl:    s,
 	}
 
-	l.regions = getRegions(getPerimeters(segments))
+	l.regions = getRegions(lg, getPerimeters(lg, segments))
 
 	return l
 }
@@ -88,10 +105,7 @@ func sliceFacet(f stl.Facet, z float64) *Segment {
 	} else if i == 2 {
 		return &Segment{From: ends[0], To: ends[1], Normal: norm}
 	} else if i == 3 {
-		dprintf("facet coincides with slice plane, ignoring")
-		// the entire facet coincides with the plane.
-		// no need to return any Segment; other facets
-		// should be sufficient to draw the perimeter
+		// the entire facet coincides with the plane
 		return nil
 	}
 
@@ -134,9 +148,59 @@ func sliceFacet(f stl.Facet, z float64) *Segment {
 	return &Segment{From: ends[0], To: ends[1], Normal: norm}
 }
 
+// degenerate returns true if f has (nearly) zero area, in which case
+// its normal is meaningless.
+func degenerate(f stl.Facet) bool {
+	v := f.Vertices[1].Sub(f.Vertices[0])
+	w := f.Vertices[2].Sub(f.Vertices[0])
+	cross := vector.V3{
+		X: (v.Y * w.Z) - (v.Z * w.Y),
+		Y: (v.Z * w.X) - (v.X * w.Z),
+		Z: (v.X * w.Y) - (v.Y * w.X),
+	}
+	return !(cross.Length() > 1e-12)
+}
+
+// degenerateFacets finds the degenerate facets of s, which can't be
+// sliced. Each is reported once, against the first layer whose plane
+// it crosses, rather than in every layer.
+func degenerateFacets(log *slog.Logger, s *stl.Solid, spans []span, diags []Diagnostics) []bool {
+	bad := make([]bool, len(s.Facets))
+	for i, f := range s.Facets {
+		if !degenerate(f) {
+			continue
+		}
+		bad[i] = true
+		minz, maxz := zRange(f)
+		for n, sp := range spans {
+			if z := sp.plane(); minz <= z && maxz >= z {
+				lg := newLogger(log, n, z, &diags[n]).at(stageSlice)
+				lg.report(Diagnostic{
+					Kind:     DegenerateFacet,
+					Location: Vertex2{f.Vertices[0].X, f.Vertices[0].Y},
+					Facet:    i,
+				})
+				break
+			}
+		}
+	}
+	return bad
+}
+
+// zRange returns the lowest and highest Z coordinates of f.
+func zRange(f stl.Facet) (min, max float64) {
+	min, max = math.Inf(+1), math.Inf(-1)
+	for _, v := range f.Vertices {
+		min = math.Min(min, v.Z)
+		max = math.Max(max, v.Z)
+	}
+	return min, max
+}
+
 // order segments into perimeters (brute force)
-func getPerimeters(segments []*Segment) [][]*Segment {
-	dprintf("finding perimeters...")
+func getPerimeters(lg logger, segments []*Segment) [][]*Segment {
+	lg = lg.at(stagePerimeters)
+	lg.debugf("finding perimeters...")
 
 	perimeters := make([][]*Segment, 0)
 	var current []*Segment
@@ -162,19 +226,36 @@ outer:
 				continue outer
 			}
 		}
-		dprintf("found %d Segment perimeter", len(current))
+		lg.debugf("found %d Segment perimeter", len(current))
 		perimeters = append(perimeters, current)
 		current = nil
 	}
 	if len(segments) != 0 {
-		wprintf("getPerimeters: segments left over after ordering: %d", len(segments))
+		lg.report(Diagnostic{
+			Kind:     OpenLoop,
+			Location: segments[0].From,
+			Facet:    -1,
+			Msg:      fmt.Sprintf("segments left over after ordering: %d", len(segments)),
+		})
+	}
+	for _, p := range perimeters {
+		first, last := p[0], p[len(p)-1]
+		if !last.To.touches(first.From) {
+			lg.report(Diagnostic{
+				Kind:     OpenLoop,
+				Location: last.To,
+				Facet:    -1,
+				Msg:      fmt.Sprintf("gap of %.3f between perimeter ends", last.To.distFrom(first.From)),
+			})
+		}
 	}
 
 	return perimeters
 }
 
-func getRegions(perimeters [][]*Segment) []*Region {
-	dprintf("grouping regions...")
+func getRegions(lg logger, perimeters [][]*Segment) []*Region {
+	lg = lg.at(stageRegions)
+	lg.debugf("grouping regions...")
 
 	regions := make([]*Region, 0)
 	Interiors := list.New()
@@ -197,11 +278,12 @@ outer:
 		r := Region{
 			Exterior:  perimeters[i],
 			Interiors: make([][]*Segment, 0),
+			log:       lg.at(stageInfill),
 		}
 		r.min, r.max = perimeterBounds(r.Exterior)
 		regions = append(regions, &r)
 	}
-	dprintf("found %d regions", len(regions))
+	lg.debugf("found %d regions", len(regions))
 
 	// sort Interiors into their solids
 	for _, r := range regions {
@@ -218,8 +300,14 @@ outer:
 			}
 		}
 	}
-	if Interiors.Len() != 0 {
-		wprintf("%d leftover Interiors", Interiors.Len())
+	for p := Interiors.Front(); p != nil; p = p.Next() {
+		v := p.Value.([]*Segment)
+		lg.report(Diagnostic{
+			Kind:     OrphanHole,
+			Location: v[0].From,
+			Facet:    -1,
+			Msg:      "interior perimeter is not inside any region",
+		})
 	}
 
 	return regions
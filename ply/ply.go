@@ -0,0 +1,300 @@
+// Package ply parses Stanford PLY files into solids.
+package ply
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"sigint.ca/slice/stl"
+	"sigint.ca/slice/vector"
+)
+
+// Parse parses a PLY file from r, in the ASCII, binary little endian or
+// binary big endian format. Polygonal faces are triangulated, and facet
+// normals follow the vertex winding, reconciled with any vertex normals
+// (nx, ny and nz properties) the file provides.
+//
+// Only the x, y and z coordinates of vertices and the vertex indices of
+// faces are read; other elements and properties are skipped.
+func Parse(r io.Reader) (*stl.Solid, error) {
+	br := bufio.NewReader(r)
+	h, err := readHeader(br)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding PLY: %v", err)
+	}
+
+	var rd valueReader
+	switch h.format {
+	case "ascii":
+		sc := bufio.NewScanner(br)
+		sc.Buffer(nil, 1<<20)
+		sc.Split(bufio.ScanWords)
+		rd = &asciiReader{sc: sc}
+	case "binary_little_endian":
+		rd = &binaryReader{r: br, order: binary.LittleEndian}
+	case "binary_big_endian":
+		rd = &binaryReader{r: br, order: binary.BigEndian}
+	default:
+		return nil, fmt.Errorf("error decoding PLY: unknown format %q", h.format)
+	}
+
+	p := &parser{rd: rd}
+	for _, e := range h.elements {
+		if err := p.readElement(e); err != nil {
+			return nil, fmt.Errorf("error decoding PLY: %s %d: %v", e.name, p.n, err)
+		}
+	}
+	s := stl.NewSolid(p.facets)
+	s.ReconcileNormals(stl.Repair)
+	return s, nil
+}
+
+// A property of an element. List properties consist of a count,
+// of type countType, followed by that many values.
+type property struct {
+	name      string
+	typ       string
+	list      bool
+	countType string
+}
+
+type element struct {
+	name  string
+	count int
+	props []property
+}
+
+type header struct {
+	format   string
+	elements []element
+}
+
+// sizes gives the size in bytes of each of the property types,
+// under both their old and new names.
+var sizes = map[string]int{
+	"char": 1, "uchar": 1, "int8": 1, "uint8": 1,
+	"short": 2, "ushort": 2, "int16": 2, "uint16": 2,
+	"int": 4, "uint": 4, "int32": 4, "uint32": 4,
+	"float": 4, "float32": 4,
+	"double": 8, "float64": 8,
+}
+
+func readHeader(r *bufio.Reader) (*header, error) {
+	h := new(header)
+	for lineno := 1; ; lineno++ {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, fmt.Errorf("header: %v", err)
+		}
+		fields := strings.Fields(line)
+		if lineno == 1 {
+			if len(fields) != 1 || fields[0] != "ply" {
+				return nil, fmt.Errorf("not a PLY file")
+			}
+			continue
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		bad := func() (*header, error) {
+			return nil, fmt.Errorf("header line %d: bad %s: %q", lineno, fields[0], strings.TrimSpace(line))
+		}
+		switch fields[0] {
+		case "format":
+			if len(fields) != 3 {
+				return bad()
+			}
+			h.format = fields[1]
+		case "element":
+			if len(fields) != 3 {
+				return bad()
+			}
+			n, err := strconv.Atoi(fields[2])
+			if err != nil || n < 0 {
+				return bad()
+			}
+			h.elements = append(h.elements, element{name: fields[1], count: n})
+		case "property":
+			if len(h.elements) == 0 {
+				return bad()
+			}
+			var p property
+			if len(fields) == 5 && fields[1] == "list" {
+				p = property{name: fields[4], typ: fields[3], list: true, countType: fields[2]}
+			} else if len(fields) == 3 {
+				p = property{name: fields[2], typ: fields[1]}
+			} else {
+				return bad()
+			}
+			if _, ok := sizes[p.typ]; !ok {
+				return bad()
+			}
+			if _, ok := sizes[p.countType]; p.list && !ok {
+				return bad()
+			}
+			e := &h.elements[len(h.elements)-1]
+			e.props = append(e.props, p)
+		case "end_header":
+			if h.format == "" {
+				return nil, fmt.Errorf("header has no format")
+			}
+			return h, nil
+		case "comment", "obj_info":
+		default:
+			return bad()
+		}
+	}
+}
+
+// A valueReader reads the values of properties from the body of a PLY file.
+type valueReader interface {
+	read(typ string) (float64, error)
+}
+
+type asciiReader struct {
+	sc *bufio.Scanner
+}
+
+func (r *asciiReader) read(typ string) (float64, error) {
+	if !r.sc.Scan() {
+		if err := r.sc.Err(); err != nil {
+			return 0, err
+		}
+		return 0, io.ErrUnexpectedEOF
+	}
+	return strconv.ParseFloat(r.sc.Text(), 64)
+}
+
+type binaryReader struct {
+	r     io.Reader
+	order binary.ByteOrder
+	buf   [8]byte
+}
+
+func (r *binaryReader) read(typ string) (float64, error) {
+	b := r.buf[:sizes[typ]]
+	if _, err := io.ReadFull(r.r, b); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, err
+	}
+	switch typ {
+	case "char", "int8":
+		return float64(int8(b[0])), nil
+	case "uchar", "uint8":
+		return float64(b[0]), nil
+	case "short", "int16":
+		return float64(int16(r.order.Uint16(b))), nil
+	case "ushort", "uint16":
+		return float64(r.order.Uint16(b)), nil
+	case "int", "int32":
+		return float64(int32(r.order.Uint32(b))), nil
+	case "uint", "uint32":
+		return float64(r.order.Uint32(b)), nil
+	case "float", "float32":
+		return float64(math.Float32frombits(r.order.Uint32(b))), nil
+	default:
+		return math.Float64frombits(r.order.Uint64(b)), nil
+	}
+}
+
+type parser struct {
+	rd       valueReader
+	n        int // index of the element being read
+	vertices []vector.V3
+	normals  []vector.V3
+	facets   []stl.Facet
+}
+
+// vertexProps gives the positions of the vertex properties we read.
+var vertexProps = map[string]int{"x": 0, "y": 1, "z": 2, "nx": 3, "ny": 4, "nz": 5}
+
+func (p *parser) readElement(e element) error {
+	var values [6]float64
+	var indices []int
+	hasNormals := false
+	for _, prop := range e.props {
+		if i, ok := vertexProps[prop.name]; ok && i >= 3 && !prop.list {
+			hasNormals = true
+		}
+	}
+	for p.n = 0; p.n < e.count; p.n++ {
+		indices = indices[:0]
+		for _, prop := range e.props {
+			if prop.list {
+				count, err := p.rd.read(prop.countType)
+				if err != nil {
+					return err
+				}
+				if count < 0 || count > 1<<20 {
+					return fmt.Errorf("bad list length %v", count)
+				}
+				for i := 0; i < int(count); i++ {
+					v, err := p.rd.read(prop.typ)
+					if err != nil {
+						return err
+					}
+					if e.name == "face" && (prop.name == "vertex_indices" || prop.name == "vertex_index") {
+						indices = append(indices, int(v))
+					}
+				}
+				continue
+			}
+
+			v, err := p.rd.read(prop.typ)
+			if err != nil {
+				return err
+			}
+			if i, ok := vertexProps[prop.name]; ok && e.name == "vertex" {
+				values[i] = v
+			}
+		}
+
+		switch e.name {
+		case "vertex":
+			p.vertices = append(p.vertices, vector.V3{X: values[0], Y: values[1], Z: values[2]})
+			if hasNormals {
+				p.normals = append(p.normals, vector.V3{X: values[3], Y: values[4], Z: values[5]})
+			}
+		case "face":
+			if err := p.addFace(indices); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (p *parser) addFace(indices []int) error {
+	if len(indices) < 3 {
+		return fmt.Errorf("need at least 3 vertices, got %d", len(indices))
+	}
+	poly := make([]vector.V3, len(indices))
+	var normal vector.V3
+	for i, vi := range indices {
+		if vi < 0 || vi >= len(p.vertices) {
+			return fmt.Errorf("vertex index %d out of range", vi)
+		}
+		poly[i] = p.vertices[vi]
+		if len(p.normals) == len(p.vertices) {
+			normal = normal.Add(p.normals[vi])
+		}
+	}
+	for _, t := range stl.Triangulate(poly) {
+		p.facets = append(p.facets, stl.Facet{
+			Vertices: [3]vector.V3{poly[t[0]], poly[t[1]], poly[t[2]]},
+			Normal:   normal,
+		})
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package ply
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// a 20mm cube made of quads, with a color property to skip
+var cubeVertices = [][3]float32{
+	{0, 0, 0}, {20, 0, 0}, {20, 20, 0}, {0, 20, 0},
+	{0, 0, 20}, {20, 0, 20}, {20, 20, 20}, {0, 20, 20},
+}
+
+var cubeFaces = [][4]int32{
+	{0, 3, 2, 1}, {4, 5, 6, 7}, {0, 1, 5, 4},
+	{1, 2, 6, 5}, {2, 3, 7, 6}, {3, 0, 4, 7},
+}
+
+func cubeHeader(format string) string {
+	return "ply\nformat " + format + ` 1.0
+comment made by hand
+element vertex 8
+property float x
+property float y
+property float z
+property uchar red
+element face 6
+property list uchar int vertex_indices
+element edge 1
+property int vertex1
+property int vertex2
+end_header
+`
+}
+
+func asciiCube() string {
+	var b strings.Builder
+	b.WriteString(cubeHeader("ascii"))
+	for _, v := range cubeVertices {
+		fmt.Fprintf(&b, "%g %g %g 255\n", v[0], v[1], v[2])
+	}
+	for _, f := range cubeFaces {
+		fmt.Fprintf(&b, "4 %d %d %d %d\n", f[0], f[1], f[2], f[3])
+	}
+	b.WriteString("0 1\n")
+	return b.String()
+}
+
+func binaryCube(t *testing.T, order binary.ByteOrder, format string) string {
+	var b bytes.Buffer
+	b.WriteString(cubeHeader(format))
+	write := func(v interface{}) {
+		if err := binary.Write(&b, order, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, v := range cubeVertices {
+		write(v)
+		write(uint8(255))
+	}
+	for _, f := range cubeFaces {
+		write(uint8(4))
+		write(f)
+	}
+	write([2]int32{0, 1})
+	return b.String()
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name, data string
+	}{
+		{"ascii", asciiCube()},
+		{"little endian", binaryCube(t, binary.LittleEndian, "binary_little_endian")},
+		{"big endian", binaryCube(t, binary.BigEndian, "binary_big_endian")},
+	}
+	for _, test := range tests {
+		s, err := Parse(strings.NewReader(test.data))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if len(s.Facets) != 12 {
+			t.Errorf("%s: got %d facets, want 12", test.name, len(s.Facets))
+		}
+		min, max := s.Bounds()
+		if min.X != 0 || min.Z != 0 || max.X != 20 || max.Z != 20 {
+			t.Errorf("%s: got bounds %v-%v", test.name, min, max)
+		}
+		if v := s.Volume(); math.Abs(v-8000) > 1e-6 {
+			t.Errorf("%s: got volume %v, want 8000", test.name, v)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	bin := binaryCube(t, binary.LittleEndian, "binary_little_endian")
+	tests := []struct {
+		name, data, err string
+	}{
+		{"magic", "solid cube\n", "not a PLY file"},
+		{"format", "ply\nformat foo 1.0\nend_header\n", `unknown format "foo"`},
+		{"property type", "ply\nformat ascii 1.0\nelement vertex 1\nproperty quad x\nend_header\n", "bad property"},
+		{"no header end", "ply\nformat ascii 1.0\n", "header: unexpected EOF"},
+		{"truncated", bin[:len(bin)-20], "face 5: unexpected EOF"},
+		{"index", "ply\nformat ascii 1.0\nelement vertex 1\nproperty float x\nproperty float y\nproperty float z\n" +
+			"element face 1\nproperty list uchar int vertex_indices\nend_header\n0 0 0\n3 0 0 1\n", "face 0: vertex index 1 out of range"},
+	}
+	for _, test := range tests {
+		_, err := Parse(strings.NewReader(test.data))
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: got error %v, want %q", test.name, err, test.err)
+		}
+	}
+}
@@ -5,4 +5,5 @@ type Region struct {
 	Exterior  []*Segment   // Exteriors perimeter
 	Interiors [][]*Segment // interior perimeters
 	Infill    []*Segment   // infill lines
+	log       logger       // the layer's logger, for use by infillers
 }
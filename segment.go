@@ -22,7 +22,7 @@ func (s *Segment) String() string {
 }
 
 func (s *Segment) Length() float64 {
-	dx := s.To.X - s.From.Y
+	dx := s.To.X - s.From.X
 	dy := s.To.Y - s.From.Y
 	return math.Sqrt(dx*dx + dy*dy)
 }
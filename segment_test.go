@@ -44,3 +44,12 @@ func TestBound(t *testing.T) {
 		t.Errorf("expected bounded.to ~= (-1,0), got %v", bounded.To)
 	}
 }
+
+func TestLength(t *testing.T) {
+	// Length once subtracted From.Y from To.X, which only gave the right
+	// answer when the two happened to be equal
+	s := &Segment{From: Vertex2{X: 1, Y: 5}, To: Vertex2{X: 4, Y: 9}}
+	if l := s.Length(); !approxEquals(l, 5, 1e-12) {
+		t.Errorf("%v.Length() = %f, want 5", s, l)
+	}
+}
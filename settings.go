@@ -0,0 +1,63 @@
+package slice
+
+import (
+	"fmt"
+
+	"sigint.ca/slice/threemf"
+)
+
+// Settings returns cfg in the form stored in 3MF packages. The logger and
+// debug mode belong to the job rather than its settings, and are not stored.
+func (cfg Config) Settings() (*threemf.Settings, error) {
+	s := &threemf.Settings{
+		LayerHeight:      cfg.LayerHeight,
+		LineWidth:        cfg.LineWidth,
+		Speed:            cfg.Speed,
+		FirstLayerHeight: cfg.FirstLayerHeight,
+		FirstLayerWidth:  cfg.FirstLayerWidth,
+		FirstLayerSpeed:  cfg.FirstLayerSpeed,
+		Adaptive:         cfg.Adaptive,
+		MinLayerHeight:   cfg.MinLayerHeight,
+		MaxLayerHeight:   cfg.MaxLayerHeight,
+		CuspHeight:       cfg.CuspHeight,
+	}
+	for _, r := range cfg.HeightRanges {
+		s.HeightRanges = append(s.HeightRanges, threemf.HeightRange(r))
+	}
+	switch in := cfg.Infill.(type) {
+	case nil:
+	case *Concentric:
+		s.Infill = &threemf.Infill{Type: "concentric", Spacing: in.Spacing}
+	default:
+		return nil, fmt.Errorf("can't store infill of type %T", in)
+	}
+	return s, nil
+}
+
+// ConfigFromSettings returns the Config stored in a 3MF package as s.
+func ConfigFromSettings(s *threemf.Settings) (Config, error) {
+	cfg := Config{
+		LayerHeight:      s.LayerHeight,
+		LineWidth:        s.LineWidth,
+		Speed:            s.Speed,
+		FirstLayerHeight: s.FirstLayerHeight,
+		FirstLayerWidth:  s.FirstLayerWidth,
+		FirstLayerSpeed:  s.FirstLayerSpeed,
+		Adaptive:         s.Adaptive,
+		MinLayerHeight:   s.MinLayerHeight,
+		MaxLayerHeight:   s.MaxLayerHeight,
+		CuspHeight:       s.CuspHeight,
+	}
+	for _, r := range s.HeightRanges {
+		cfg.HeightRanges = append(cfg.HeightRanges, HeightRange(r))
+	}
+	if s.Infill != nil {
+		switch s.Infill.Type {
+		case "concentric":
+			cfg.Infill = &Concentric{Spacing: s.Infill.Spacing}
+		default:
+			return Config{}, fmt.Errorf("unknown infill type %q", s.Infill.Type)
+		}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,37 @@
+package slice
+
+import (
+	"reflect"
+	"testing"
+
+	"sigint.ca/slice/threemf"
+)
+
+func TestSettings(t *testing.T) {
+	cfg := Config{
+		Logger:           discardLogger,
+		LayerHeight:      0.2,
+		LineWidth:        0.4,
+		Speed:            50,
+		FirstLayerHeight: 0.3,
+		HeightRanges:     []HeightRange{{Min: 5, Max: 10, Height: 0.1}},
+		Infill:           &Concentric{Spacing: 0.5},
+	}
+	s, err := cfg.Settings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ConfigFromSettings(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the logger isn't stored
+	cfg.Logger = nil
+	if !reflect.DeepEqual(out, cfg) {
+		t.Errorf("got config %+v, want %+v", out, cfg)
+	}
+
+	if _, err := ConfigFromSettings(&threemf.Settings{Infill: &threemf.Infill{Type: "gyroid"}}); err == nil {
+		t.Error("unknown infill type was accepted")
+	}
+}
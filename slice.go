@@ -5,65 +5,110 @@
 package slice
 
 import (
-	"fmt"
-	"os"
+	"log/slog"
 	"sync"
 
 	"sigint.ca/slice/stl"
 )
 
-var debug bool
-
 // A Config variable specifies a slicing configuration.
 type Config struct {
 	DebugMode bool
 
+	// Logger receives debugging output and warnings, annotated with the
+	// layer index and slicing stage. If nil, warnings are written to
+	// stderr, along with debugging output in DebugMode.
+	Logger *slog.Logger
+
 	LayerHeight float64
 	LineWidth   float64
+	Speed       float64 // print speed in mm/s
+
+	// The first layer is often printed thicker, wider and slower than the
+	// rest to help it stick to the bed. These settings override LayerHeight,
+	// LineWidth and Speed for the first layer only, if non-zero.
+	FirstLayerHeight float64
+	FirstLayerWidth  float64
+	FirstLayerSpeed  float64
+
+	// If Adaptive is set, LayerHeight is ignored and each layer's height is
+	// chosen between MinLayerHeight and MaxLayerHeight according to the slope
+	// of the surfaces it passes through, keeping the stair-stepping on sloped
+	// surfaces below CuspHeight. If CuspHeight is zero, MinLayerHeight is used.
+	Adaptive       bool
+	MinLayerHeight float64
+	MaxLayerHeight float64
+	CuspHeight     float64
+
+	// HeightRanges override the layer height within their Z range,
+	// regardless of the settings above. They must not overlap.
+	HeightRanges []HeightRange
 
 	Infill Infiller
 }
 
-func dprintf(format string, args ...interface{}) {
-	if debug {
-		fmt.Fprintf(os.Stderr, "[ "+format+" ]\n", args...)
+// lineWidth returns the line width for layer n.
+func (cfg Config) lineWidth(n int) float64 {
+	if n == 0 && cfg.FirstLayerWidth != 0 {
+		return cfg.FirstLayerWidth
 	}
+	return cfg.LineWidth
 }
 
-func wprintf(format string, args ...interface{}) {
-	fmt.Fprintf(os.Stderr, "WARNING: "+format+"\n", args...)
+// speed returns the print speed for layer n.
+func (cfg Config) speed(n int) float64 {
+	if n == 0 && cfg.FirstLayerSpeed != 0 {
+		return cfg.FirstLayerSpeed
+	}
+	return cfg.Speed
 }
 
-// Slice slices and stl.Solid into layers.
-func Slice(s *stl.Solid, cfg Config) ([]*Layer, error) {
-	debug = cfg.DebugMode
+// Slice slices and stl.Solid into layers. Any warnings encountered along
+// the way are returned as diagnostics.
+func Slice(s *stl.Solid, cfg Config) ([]*Layer, Diagnostics, error) {
+	if err := checkHeights(cfg); err != nil {
+		return nil, nil, err
+	}
+
+	log := cfg.Logger
+	if log == nil {
+		log = defaultLogger(cfg.DebugMode)
+	}
 
-	min, max := s.Bounds()
-	nLayers := int(0.5 + (max.Z-min.Z)/cfg.LayerHeight)
-	layers := make([]*Layer, nLayers)
-	h := cfg.LayerHeight
+	spans := layerSpans(s, cfg)
+	layers := make([]*Layer, len(spans))
+	diags := make([]Diagnostics, len(spans)) // one list per layer, to avoid locking
+	skip := degenerateFacets(log, s, spans, diags)
 
 	// slice in parallel if not in debug mode
-	if debug {
-		for i := range layers {
-			layers[i] = sliceLayer(i, min.Z+0.01+float64(i)*h, s, cfg)
+	if cfg.DebugMode {
+		for i, sp := range spans {
+			lg := newLogger(log, i, sp.plane(), &diags[i])
+			layers[i] = sliceLayer(lg, i, sp, s, skip, cfg)
 			for _, r := range layers[i].regions {
 				cfg.Infill.Fill(r)
 			}
 		}
 	} else {
 		var wg sync.WaitGroup
-		for i := range layers {
+		for i, sp := range spans {
 			wg.Add(1)
-			go func(i int, z float64) {
-				layers[i] = sliceLayer(i, z, s, cfg)
+			go func(i int, sp span) {
+				lg := newLogger(log, i, sp.plane(), &diags[i])
+				layers[i] = sliceLayer(lg, i, sp, s, skip, cfg)
 				//layers[i].genInfill(cfg)
 				wg.Done()
-			}(i, min.Z+0.01+float64(i)*h)
+			}(i, sp)
 		}
 		wg.Wait()
 	}
 
-	dprintf("sliced %d layers", nLayers)
-	return layers, nil
+	var all Diagnostics
+	for i, d := range diags {
+		layers[i].diags = d
+		all = append(all, d...)
+	}
+
+	log.Debug("sliced layers", "n", len(layers))
+	return layers, all, nil
 }
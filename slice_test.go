@@ -1,12 +1,20 @@
 package slice
 
 import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
 	"os"
+	"sync"
 	"testing"
 
 	"sigint.ca/slice/stl"
+	"sigint.ca/slice/vector"
 )
 
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
 func TestSlice(t *testing.T) {
 	t.Log("opening stl file")
 	f, err := os.Open("testdata/pikachu.stl")
@@ -25,9 +33,117 @@ func TestSlice(t *testing.T) {
 		LayerHeight: 1.0,
 		LineWidth:   1.0,
 	}
-	layers, err := Slice(stl, cfg)
+	layers, _, err := Slice(stl, cfg)
 	if err != nil {
 		t.Fatal(err)
 	}
 	t.Logf("sliced %d layers", len(layers))
 }
+
+func TestSliceLogger(t *testing.T) {
+	f, err := os.Open("testdata/pikachu.stl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stl, err := stl.Parse(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// slice twice concurrently, each job with its own logger
+	var bufs [2]bytes.Buffer
+	var wg sync.WaitGroup
+	for i := range bufs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cfg := Config{
+				DebugMode:   i == 0,
+				Logger:      slog.New(slog.NewJSONHandler(&bufs[i], &slog.HandlerOptions{Level: slog.LevelDebug})),
+				LayerHeight: 1.0,
+				LineWidth:   1.0,
+				Infill:      &Concentric{Spacing: 0.2},
+			}
+			if _, _, err := Slice(stl, cfg); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range bufs {
+		dec := json.NewDecoder(&bufs[i])
+		var n int
+		for dec.More() {
+			var rec map[string]interface{}
+			if err := dec.Decode(&rec); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := rec["stage"]; !ok {
+				continue
+			}
+			if _, ok := rec["layer"]; !ok {
+				t.Errorf("job %d: record without layer: %v", i, rec)
+			}
+			n++
+		}
+		if n == 0 {
+			t.Errorf("job %d: no layer records logged", i)
+		}
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	// an open box: the +x wall is missing, so every layer has an open loop
+	wall := func(a, b, c, d vector.V3) []stl.Facet {
+		return []stl.Facet{
+			{Vertices: [3]vector.V3{a, b, c}},
+			{Vertices: [3]vector.V3{a, c, d}},
+		}
+	}
+	var facets []stl.Facet
+	facets = append(facets, wall(
+		vector.V3{X: 0, Y: 0, Z: 0}, vector.V3{X: 10, Y: 0, Z: 0},
+		vector.V3{X: 10, Y: 0, Z: 10}, vector.V3{X: 0, Y: 0, Z: 10})...)
+	facets = append(facets, wall(
+		vector.V3{X: 0, Y: 10, Z: 0}, vector.V3{X: 0, Y: 0, Z: 0},
+		vector.V3{X: 0, Y: 0, Z: 10}, vector.V3{X: 0, Y: 10, Z: 10})...)
+	facets = append(facets, wall(
+		vector.V3{X: 10, Y: 10, Z: 0}, vector.V3{X: 0, Y: 10, Z: 0},
+		vector.V3{X: 0, Y: 10, Z: 10}, vector.V3{X: 10, Y: 10, Z: 10})...)
+	// and a degenerate sliver
+	facets = append(facets, stl.Facet{Vertices: [3]vector.V3{
+		{X: 5, Y: 5, Z: 0}, {X: 5, Y: 5, Z: 5}, {X: 5, Y: 5, Z: 10},
+	}})
+	s := stl.NewSolid(facets)
+
+	cfg := Config{
+		Logger:      discardLogger,
+		LayerHeight: 1.0,
+		LineWidth:   1.0,
+	}
+	layers, diags, err := Slice(s, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := diags.Count()
+	if count[OpenLoop] != len(layers) {
+		t.Errorf("got %d open loops, want %d", count[OpenLoop], len(layers))
+	}
+	// reported once, not in every layer the facet crosses
+	if count[DegenerateFacet] != 1 {
+		t.Errorf("got %d degenerate facets, want 1", count[DegenerateFacet])
+	}
+	for _, d := range diags.Filter(DegenerateFacet) {
+		if d.Facet != 6 || d.Layer != 0 {
+			t.Errorf("degenerate facet reported at index %d in layer %d, want 6 in layer 0", d.Facet, d.Layer)
+		}
+	}
+	if len(layers[0].Diagnostics()) != len(diags.Layer(0)) {
+		t.Errorf("layer 0 has %d diagnostics, want %d", len(layers[0].Diagnostics()), len(diags.Layer(0)))
+	}
+	if diags.Err() == nil {
+		t.Error("Err() == nil for broken model")
+	}
+}
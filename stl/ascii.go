@@ -4,70 +4,236 @@ import (
 	"bufio"
 	"bytes"
 	"fmt"
+	"io"
+	"strconv"
 
 	"sigint.ca/slice/vector"
 )
 
+// A SyntaxError describes malformed ASCII STL input.
+type SyntaxError struct {
+	Line, Col int // position of the offending token, counting from 1
+	Msg       string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d, col %d: %s", e.Line, e.Col, e.Msg)
+}
+
+// asciiReader parses ASCII STLs one whitespace-separated token at a time,
+// so it doesn't care about line layout, indentation, line endings or the
+// case of keywords.
 type asciiReader struct {
-	r *bufio.Reader
+	r         *bufio.Reader
+	line, col int    // position of the next byte
+	tok       []byte // the current token
+	tokLine   int    // position of the current token
+	tokCol    int
+
+	names   []string // names of the solids started so far
+	inSolid bool     // between "solid" and "endsolid"
+	done    bool     // the input ended cleanly
 }
 
-func (p asciiReader) readFacets() ([]Facet, error) {
-	// discard header
-	p.r.ReadLine()
+func newASCIIReader(r *bufio.Reader) *asciiReader {
+	return &asciiReader{r: r, line: 1, col: 1}
+}
 
-	facets := make([]Facet, 0)
+// next returns the next facet, or io.EOF after the last solid has ended.
+func (p *asciiReader) next() (Facet, error) {
+	f, err := p.nextFacet()
+	if err != nil && !p.done {
+		return Facet{}, p.wrap(err)
+	}
+	return f, err
+}
+
+func (p *asciiReader) nextFacet() (Facet, error) {
 	for {
-		p.r.ReadLine() // discard normal
-		p.r.ReadLine() // discard "outer loop"
+		err := p.scan()
+		if err == io.EOF && !p.inSolid && len(p.names) > 0 {
+			p.done = true
+			return Facet{}, io.EOF
+		} else if err != nil {
+			return Facet{}, err
+		}
 
-		f, err := p.readFacet()
-		if err != nil {
-			return nil, fmt.Errorf("error decoding ascii STL: %v", err)
+		if !p.inSolid {
+			if !p.is("solid") {
+				return Facet{}, p.errorf("expected %q, found %q", "solid", p.tok)
+			}
+			name, err := p.restOfLine()
+			if err != nil {
+				return Facet{}, err
+			}
+			p.names = append(p.names, name)
+			p.inSolid = true
+			continue
 		}
-		facets = append(facets, f)
 
-		p.r.ReadLine() // discard "endloop"
-		p.r.ReadLine() // discard "endfacet"
+		if p.is("endsolid") {
+			// the name is optional, and often doesn't match
+			if _, err := p.restOfLine(); err != nil {
+				return Facet{}, err
+			}
+			p.inSolid = false
+			continue
+		}
+		if !p.is("facet") {
+			return Facet{}, p.errorf("expected %q or %q, found %q", "facet", "endsolid", p.tok)
+		}
+		return p.readFacet()
+	}
+}
 
-		nextWord, err := p.r.Peek(8)
+// wrap adds context to an error encountered while parsing.
+func (p *asciiReader) wrap(err error) error {
+	if err == io.EOF {
+		err = p.errorf("unexpected end of file")
+	}
+	return fmt.Errorf("error decoding ascii STL: %w", err)
+}
+
+// readFacet reads the remainder of a facet, after the "facet" keyword.
+func (p *asciiReader) readFacet() (Facet, error) {
+	var f Facet
+	if err := p.expect("normal"); err != nil {
+		return f, err
+	}
+	n, err := p.readV3()
+	if err != nil {
+		return f, err
+	}
+	f.Normal = n
+	if err := p.expect("outer"); err != nil {
+		return f, err
+	}
+	if err := p.expect("loop"); err != nil {
+		return f, err
+	}
+	for i := range f.Vertices {
+		if err := p.expect("vertex"); err != nil {
+			return f, err
+		}
+		v, err := p.readV3()
 		if err != nil {
-			return nil, fmt.Errorf("error decoding ascii STL: %v", err)
+			return f, err
 		}
-		if string(nextWord) == "endsolid" {
-			break
+		f.Vertices[i] = v
+	}
+	if err := p.expect("endloop"); err != nil {
+		return f, err
+	}
+	if err := p.expect("endfacet"); err != nil {
+		return f, err
+	}
+	return f, nil
+}
+
+func (p *asciiReader) readV3() (vector.V3, error) {
+	var xyz [3]float64
+	for i := range xyz {
+		if err := p.scan(); err != nil {
+			return vector.V3{}, err
+		}
+		// values are single precision, as in binary STLs
+		v, err := strconv.ParseFloat(string(p.tok), 32)
+		if err != nil {
+			return vector.V3{}, p.errorf("bad number %q", p.tok)
 		}
+		xyz[i] = v
+	}
+	return vector.V3{X: xyz[0], Y: xyz[1], Z: xyz[2]}, nil
+}
+
+// expect reads the next token, which must be keyword.
+func (p *asciiReader) expect(keyword string) error {
+	if err := p.scan(); err != nil {
+		return err
 	}
+	if !p.is(keyword) {
+		return p.errorf("expected %q, found %q", keyword, p.tok)
+	}
+	return nil
+}
+
+// is reports whether the current token is keyword, ignoring case.
+func (p *asciiReader) is(keyword string) bool {
+	return len(p.tok) == len(keyword) && bytes.EqualFold(p.tok, []byte(keyword))
+}
 
-	return facets, nil
+func (p *asciiReader) errorf(format string, args ...interface{}) error {
+	return &SyntaxError{Line: p.tokLine, Col: p.tokCol, Msg: fmt.Sprintf(format, args...)}
 }
 
-func (p asciiReader) readFacet() (Facet, error) {
-	var vertices [3]vector.V3
-	for vi := range vertices {
-		v, err := p.readVertex()
+// scan reads the next token. It returns io.EOF if there are no more tokens.
+func (p *asciiReader) scan() error {
+	for {
+		c, err := p.peek()
 		if err != nil {
-			return Facet{}, fmt.Errorf("read facet: %v", err)
+			p.tokLine, p.tokCol = p.line, p.col
+			return err
+		}
+		if !isSpace(c) {
+			break
 		}
-		vertices[vi] = v
+		p.advance(c)
+	}
+
+	p.tokLine, p.tokCol = p.line, p.col
+	p.tok = p.tok[:0]
+	for {
+		c, err := p.peek()
+		if err == io.EOF || err == nil && isSpace(c) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		p.tok = append(p.tok, c)
+		p.advance(c)
 	}
-	f := Facet{Vertices: vertices}
-	f.calculateNormal()
-	return f, nil
 }
 
-func (p asciiReader) readVertex() (vector.V3, error) {
-	var x, z, y float32
+// restOfLine consumes and returns the remainder of the current line,
+// without surrounding whitespace.
+func (p *asciiReader) restOfLine() (string, error) {
+	var buf []byte
+	for {
+		c, err := p.peek()
+		if err == io.EOF || err == nil && c == '\n' {
+			break
+		} else if err != nil {
+			return "", err
+		}
+		buf = append(buf, c)
+		p.advance(c)
+	}
+	return string(bytes.TrimSpace(buf)), nil
+}
 
-	s, _, err := p.r.ReadLine()
+func (p *asciiReader) peek() (byte, error) {
+	b, err := p.r.Peek(1)
 	if err != nil {
-		return vector.V3{}, fmt.Errorf("read vertex: %v", err)
+		return 0, err
 	}
-	s = bytes.TrimSpace(s)
+	return b[0], nil
+}
+
+// advance consumes c, which must have just been peeked.
+func (p *asciiReader) advance(c byte) {
+	p.r.Discard(1)
+	if c == '\n' {
+		p.line++
+		p.col = 1
+	} else {
+		p.col++
+	}
+}
 
-	if _, err := fmt.Sscanf(string(s), "vertex %f %f %f\n", &x, &y, &z); err != nil {
-		return vector.V3{}, fmt.Errorf("read vertex: %q: %v", s, err)
+func isSpace(c byte) bool {
+	switch c {
+	case ' ', '\t', '\n', '\r', '\v', '\f':
+		return true
 	}
-	v := vector.V3{X: float64(x), Y: float64(y), Z: float64(z)}
-	return v, nil
+	return false
 }
@@ -0,0 +1,51 @@
+package stl
+
+import (
+	"fmt"
+
+	"sigint.ca/slice/vector"
+)
+
+// A Bed is the build volume of a printer, in millimeters. Its origin is
+// the front left corner of the bed surface, with Z pointing up.
+type Bed struct {
+	Width  float64 // along X
+	Depth  float64 // along Y
+	Height float64 // along Z
+}
+
+// Center returns the middle of the bed surface.
+func (b Bed) Center() vector.V3 {
+	return vector.V3{X: b.Width / 2, Y: b.Depth / 2}
+}
+
+// Check returns an error if s does not fit inside the build volume where
+// it is placed, saying whether it is too big or just needs moving.
+func (b Bed) Check(s *Solid) error {
+	min, max := s.Bounds()
+	size := max.Sub(min)
+	if size.X > b.Width || size.Y > b.Depth || size.Z > b.Height {
+		return fmt.Errorf("model is %.1f x %.1f x %.1f mm, too big for the %.1f x %.1f x %.1f mm build volume",
+			size.X, size.Y, size.Z, b.Width, b.Depth, b.Height)
+	}
+	if min.X < 0 || min.Y < 0 || min.Z < 0 || max.X > b.Width || max.Y > b.Depth || max.Z > b.Height {
+		return fmt.Errorf("model at %v-%v extends outside the %.1f x %.1f x %.1f mm build volume",
+			min, max, b.Width, b.Depth, b.Height)
+	}
+	return nil
+}
+
+// PlaceOnBed moves s vertically so that its lowest point is at Z=0.
+func (s *Solid) PlaceOnBed() {
+	min, _ := s.Bounds()
+	// 0-z rather than -z, so that no vertex is left at -0
+	s.Translate(vector.V3{Z: 0 - min.Z})
+}
+
+// CenterOn moves s horizontally so that it is centered on b.
+func (s *Solid) CenterOn(b Bed) {
+	min, max := s.Bounds()
+	mid := min.Add(max).Mul(0.5)
+	c := b.Center()
+	s.Translate(vector.V3{X: c.X - mid.X, Y: c.Y - mid.Y})
+}
@@ -0,0 +1,55 @@
+package stl
+
+import (
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	"sigint.ca/slice/vector"
+)
+
+func TestBed(t *testing.T) {
+	f, err := os.Open("../testdata/pikachu.stl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	s, err := Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Translate(vector.V3{X: -500, Y: 30, Z: 12.5})
+
+	bed := Bed{Width: 200, Depth: 150, Height: 100}
+	if err := bed.Check(s); err == nil || !strings.Contains(err.Error(), "extends outside") {
+		t.Errorf("got error %v for model off the bed", err)
+	}
+
+	s.PlaceOnBed()
+	s.CenterOn(bed)
+	min, max := s.Bounds()
+	if min.Z != 0 || math.Signbit(min.Z) {
+		t.Errorf("got min z %v, want 0", min.Z)
+	}
+	for _, f := range s.Facets {
+		for _, v := range f.Vertices {
+			if math.Signbit(v.Z) {
+				t.Fatalf("vertex %v is below the bed", v)
+			}
+		}
+	}
+	mid := min.Add(max).Mul(0.5)
+	if math.Abs(mid.X-100) > 1e-9 || math.Abs(mid.Y-75) > 1e-9 {
+		t.Errorf("got center %v, want (100, 75)", mid)
+	}
+	if err := bed.Check(s); err != nil {
+		t.Error(err)
+	}
+
+	small := Bed{Width: 10, Depth: 10, Height: 10}
+	s.CenterOn(small)
+	if err := small.Check(s); err == nil || !strings.Contains(err.Error(), "too big") {
+		t.Errorf("got error %v for model bigger than the bed", err)
+	}
+}
@@ -4,55 +4,129 @@ import (
 	"bufio"
 	"encoding/binary"
 	"fmt"
+	"io"
 	"math"
+	"runtime"
+	"sync"
 
-	"io"
+	"sigint.ca/slice/vector"
 )
 
 type binaryReader struct {
-	r *bufio.Reader
+	r       *bufio.Reader
+	header  []byte
+	count   uint32 // number of facets according to the header
+	checked bool   // whether count has been checked against the input size
+	read    uint32 // number of facets read so far
+	buf     []byte // reusable buffer for reading facets
 }
 
-func (p binaryReader) readFacets() ([]Facet, error) {
-	// discard header
-	if _, err := p.r.Discard(80); err != nil {
+// newBinaryReader reads the header and facet count of a binary STL.
+// If size is non-negative, it is used to reject bad facet counts.
+func newBinaryReader(r *bufio.Reader, size int64) (*binaryReader, error) {
+	p := &binaryReader{
+		r:      r,
+		header: make([]byte, 80),
+		buf:    make([]byte, 50),
+	}
+	if _, err := io.ReadFull(r, p.header); err != nil {
 		return nil, fmt.Errorf("error decoding STL: %v", err)
 	}
-
-	var nfacets uint32
-	if err := binary.Read(p.r, binary.LittleEndian, &nfacets); err != nil {
+	if err := binary.Read(r, binary.LittleEndian, &p.count); err != nil {
 		return nil, fmt.Errorf("error decoding STL: %v", err)
 	}
+	if size >= 0 && 84+50*int64(p.count) > size {
+		return nil, fmt.Errorf("error decoding STL: header claims %d facets, but there is only room for %d",
+			p.count, (size-84)/50)
+	}
+	p.checked = size >= 0
+	return p, nil
+}
 
-	facets := make([]Facet, nfacets)
-	var vbuf = make([]byte, 12) // reusable buffer for reading vertices
-	for i := range facets {
-		p.r.Discard(12) // discard normal
+// next returns the next facet, or io.EOF once the number of
+// facets given in the header have been read.
+func (p *binaryReader) next() (Facet, error) {
+	var f Facet
+	if p.read == p.count {
+		return f, io.EOF
+	}
+	if _, err := io.ReadFull(p.r, p.buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return f, fmt.Errorf("error decoding STL: facet %d of %d: %v", p.read, p.count, err)
+	}
+	decodeFacet(&f, p.buf)
+	p.read++
+	return f, nil
+}
 
-		var f Facet
-		if err := p.readFacet(&f, vbuf); err != nil {
-			return nil, err
+// readAll reads the remaining facets in bulk and decodes them in parallel,
+// reconciling their normals according to policy. It returns the facets and
+// the indices of those whose normals disagreed.
+func (p *binaryReader) readAll(policy NormalPolicy) ([]Facet, []int, error) {
+	n := int(p.count - p.read)
+	var payload []byte
+	if p.checked {
+		payload = make([]byte, 50*n)
+		if _, err := io.ReadFull(p.r, payload); err != nil {
+			return nil, nil, fmt.Errorf("error decoding STL: %v", err)
 		}
-		facets[i] = f
+	} else {
+		// the count may be garbage, so only allocate as the data arrives
+		var err error
+		payload, err = io.ReadAll(io.LimitReader(p.r, 50*int64(n)))
+		if err != nil {
+			return nil, nil, fmt.Errorf("error decoding STL: %v", err)
+		}
+		if len(payload) < 50*n {
+			return nil, nil, fmt.Errorf("error decoding STL: facet %d of %d: %v",
+				int(p.read)+len(payload)/50, p.count, io.ErrUnexpectedEOF)
+		}
+	}
 
-		if _, err := p.r.Discard(2); err != nil {
-			return nil, fmt.Errorf("error decoding STL: %v", err)
+	facets := make([]Facet, n)
+	workers := runtime.GOMAXPROCS(0)
+	chunk := (n + workers - 1) / workers
+	mismatches := make([][]int, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w*chunk < n; w++ {
+		start, end := w*chunk, (w+1)*chunk
+		if end > n {
+			end = n
 		}
+		wg.Add(1)
+		go func(w, start, end int) {
+			for i := start; i < end; i++ {
+				decodeFacet(&facets[i], payload[50*i:50*i+50])
+				if policy.fix(&facets[i]) {
+					mismatches[w] = append(mismatches[w], int(p.read)+i)
+				}
+			}
+			wg.Done()
+		}(w, start, end)
 	}
+	wg.Wait()
+	p.read = p.count
 
-	return facets, nil
+	var all []int
+	for _, m := range mismatches {
+		all = append(all, m...)
+	}
+	return facets, all, nil
 }
 
-func (p binaryReader) readFacet(f *Facet, vbuf []byte) error {
+// decodeFacet decodes a 50 byte binary facet record from b.
+func decodeFacet(f *Facet, b []byte) {
+	decodeV3(&f.Normal, b[0:12])
 	for i := range f.Vertices {
-		_, err := io.ReadFull(p.r, vbuf)
-		if err != nil {
-			return err
-		}
-		f.Vertices[i].X = float64(math.Float32frombits(binary.LittleEndian.Uint32(vbuf[0:4])))
-		f.Vertices[i].Y = float64(math.Float32frombits(binary.LittleEndian.Uint32(vbuf[4:8])))
-		f.Vertices[i].Z = float64(math.Float32frombits(binary.LittleEndian.Uint32(vbuf[8:12])))
+		decodeV3(&f.Vertices[i], b[12+12*i:24+12*i])
 	}
-	f.calculateNormal()
-	return nil
+	f.Attr = binary.LittleEndian.Uint16(b[48:50])
+}
+
+func decodeV3(v *vector.V3, b []byte) {
+	v.X = float64(math.Float32frombits(binary.LittleEndian.Uint32(b[0:4])))
+	v.Y = float64(math.Float32frombits(binary.LittleEndian.Uint32(b[4:8])))
+	v.Z = float64(math.Float32frombits(binary.LittleEndian.Uint32(b[8:12])))
 }
@@ -0,0 +1,147 @@
+package stl
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+)
+
+// A ColorScheme identifies how the colors of a binary STL are encoded in
+// the attribute bytes of its facets.
+//
+// Both common schemes pack a 15-bit color into the attribute word, five bits
+// per channel, but they differ in the order of the channels and in the
+// meaning of the top bit. VisCAM and SolidView put blue in the low bits and
+// set the top bit for colored facets. Materialise Magics puts red in the low
+// bits, clears the top bit for colored facets, and stores the color of the
+// other facets in the header as "COLOR=" followed by RGBA bytes.
+type ColorScheme int
+
+const (
+	VisCAM ColorScheme = iota
+	Materialise
+)
+
+func (c ColorScheme) String() string {
+	switch c {
+	case VisCAM:
+		return "VisCAM"
+	case Materialise:
+		return "Materialise"
+	}
+	return fmt.Sprintf("ColorScheme(%d)", int(c))
+}
+
+// A Material is the default material of a solid, as stored in the
+// "MATERIAL=" field of a Materialise binary STL header.
+type Material struct {
+	Diffuse, Specular, Ambient color.RGBA
+}
+
+// ColorScheme guesses the color scheme used by s from its header.
+func (s *Solid) ColorScheme() ColorScheme {
+	if bytes.Contains(s.Header, []byte("COLOR=")) || bytes.Contains(s.Header, []byte("MATERIAL=")) {
+		return Materialise
+	}
+	return VisCAM
+}
+
+// HeaderColor returns the default color of s from the "COLOR=" field
+// of its header, if there is one.
+func (s *Solid) HeaderColor() (color.RGBA, bool) {
+	b, ok := headerField(s.Header, "COLOR=", 4)
+	if !ok {
+		return color.RGBA{}, false
+	}
+	return color.RGBA{R: b[0], G: b[1], B: b[2], A: b[3]}, true
+}
+
+// HeaderMaterial returns the default material of s from the "MATERIAL="
+// field of its header, if there is one.
+func (s *Solid) HeaderMaterial() (Material, bool) {
+	b, ok := headerField(s.Header, "MATERIAL=", 12)
+	if !ok {
+		return Material{}, false
+	}
+	rgba := func(b []byte) color.RGBA {
+		return color.RGBA{R: b[0], G: b[1], B: b[2], A: b[3]}
+	}
+	return Material{Diffuse: rgba(b[0:4]), Specular: rgba(b[4:8]), Ambient: rgba(b[8:12])}, true
+}
+
+// headerField returns the n bytes following key in header.
+func headerField(header []byte, key string, n int) ([]byte, bool) {
+	i := bytes.Index(header, []byte(key))
+	if i < 0 || i+len(key)+n > len(header) {
+		return nil, false
+	}
+	i += len(key)
+	return header[i : i+n], true
+}
+
+// FacetColor returns the color of facet i, decoded according to the
+// color scheme of s, or false if it has none. With the Materialise scheme,
+// facets without a color of their own get the color from the header, if any.
+//
+// FacetColor examines the header on every call; to decode every facet,
+// use Colors.
+func (s *Solid) FacetColor(i int) (color.RGBA, bool) {
+	return s.colorDecoder().decode(s.Facets[i].Attr)
+}
+
+// Colors returns the distinct facet colors of s, in order of appearance.
+func (s *Solid) Colors() []color.RGBA {
+	d := s.colorDecoder()
+	seen := make(map[color.RGBA]bool)
+	colors := make([]color.RGBA, 0)
+	for _, f := range s.Facets {
+		c, ok := d.decode(f.Attr)
+		if ok && !seen[c] {
+			seen[c] = true
+			colors = append(colors, c)
+		}
+	}
+	return colors
+}
+
+// A colorDecoder decodes facet attribute words according to the color
+// scheme and default color of a solid, read from its header once.
+type colorDecoder struct {
+	scheme    ColorScheme
+	header    color.RGBA
+	hasHeader bool
+}
+
+func (s *Solid) colorDecoder() colorDecoder {
+	d := colorDecoder{scheme: s.ColorScheme()}
+	if d.scheme == Materialise {
+		d.header, d.hasHeader = s.HeaderColor()
+	}
+	return d
+}
+
+// decode returns the color encoded in attr, or false if there is none.
+func (d colorDecoder) decode(attr uint16) (color.RGBA, bool) {
+	if d.scheme == VisCAM {
+		if attr&0x8000 == 0 {
+			return color.RGBA{}, false
+		}
+		return color.RGBA{R: expand5(attr >> 10), G: expand5(attr >> 5), B: expand5(attr), A: 0xFF}, true
+	}
+	if attr&0x8000 != 0 {
+		return d.header, d.hasHeader
+	}
+	return color.RGBA{R: expand5(attr), G: expand5(attr >> 5), B: expand5(attr >> 10), A: 0xFF}, true
+}
+
+// expand5 scales the 5-bit channel in the low bits of v to 8 bits.
+func expand5(v uint16) uint8 {
+	c := uint8(v & 0x1F)
+	return c<<3 | c>>2
+}
+
+// VisCAMAttr returns the attribute word encoding c in the VisCAM scheme.
+func VisCAMAttr(c color.Color) uint16 {
+	r, g, b, _ := c.RGBA()
+	return 0x8000 | uint16(r>>11)<<10 | uint16(g>>11)<<5 | uint16(b>>11)
+}
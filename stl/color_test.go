@@ -0,0 +1,84 @@
+package stl
+
+import (
+	"bytes"
+	"image/color"
+	"testing"
+
+	"sigint.ca/slice/vector"
+)
+
+func colorTestSolid(header []byte, attrs ...uint16) *Solid {
+	facets := make([]Facet, len(attrs))
+	for i, a := range attrs {
+		facets[i] = Facet{
+			Vertices: [3]vector.V3{{X: float64(i)}, {X: float64(i) + 1}, {X: float64(i), Y: 1}},
+			Attr:     a,
+		}
+	}
+	s := NewSolid(facets)
+	s.Header = header
+	return s
+}
+
+func TestColors(t *testing.T) {
+	red := color.RGBA{R: 0xFF, A: 0xFF}
+	blue := color.RGBA{B: 0xFF, A: 0xFF}
+	grey := color.RGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xFF}
+
+	header := make([]byte, 80)
+	copy(header, "COLOR=\x80\x80\x80\xFF,MATERIAL=\x01\x02\x03\x04\x05\x06\x07\x08\x09\x0a\x0b\x0c")
+
+	tests := []struct {
+		name   string
+		solid  *Solid
+		scheme ColorScheme
+		colors []color.RGBA // per facet; zero for none
+	}{
+		{
+			name:   "viscam",
+			solid:  colorTestSolid(nil, VisCAMAttr(red), 0, VisCAMAttr(blue)),
+			scheme: VisCAM,
+			colors: []color.RGBA{red, {}, blue},
+		},
+		{
+			name:   "materialise",
+			solid:  colorTestSolid(header, 0x001F, 0x8000, 0x7C00),
+			scheme: Materialise,
+			colors: []color.RGBA{red, grey, blue},
+		},
+	}
+
+	for _, test := range tests {
+		// round trip through the binary format first
+		var buf bytes.Buffer
+		if err := WriteBinary(&buf, test.solid); err != nil {
+			t.Fatal(err)
+		}
+		s, err := Parse(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if s.ColorScheme() != test.scheme {
+			t.Errorf("%s: scheme=%v, want %v", test.name, s.ColorScheme(), test.scheme)
+		}
+		for i, want := range test.colors {
+			got, ok := s.FacetColor(i)
+			if ok != (want != color.RGBA{}) || got != want {
+				t.Errorf("%s: facet %d: color=%v (%v), want %v", test.name, i, got, ok, want)
+			}
+		}
+	}
+
+	s := colorTestSolid(header)
+	m, ok := s.HeaderMaterial()
+	want := Material{
+		Diffuse:  color.RGBA{R: 1, G: 2, B: 3, A: 4},
+		Specular: color.RGBA{R: 5, G: 6, B: 7, A: 8},
+		Ambient:  color.RGBA{R: 9, G: 10, B: 11, A: 12},
+	}
+	if !ok || m != want {
+		t.Errorf("material=%v (%v), want %v", m, ok, want)
+	}
+}
@@ -0,0 +1,84 @@
+package stl
+
+import "sigint.ca/slice/vector"
+
+// Volume returns the volume enclosed by s, in cubic units of its
+// coordinates. It is negative if s is wound inside out, and only
+// meaningful if s is watertight.
+func (s *Solid) Volume() float64 {
+	var v float64
+	for _, f := range s.Facets {
+		a, b, c := f.Vertices[0], f.Vertices[1], f.Vertices[2]
+		v += a.Dot(b.Cross(c)) / 6
+	}
+	return v
+}
+
+// SurfaceArea returns the total area of the facets of s.
+func (s *Solid) SurfaceArea() float64 {
+	var area float64
+	for _, f := range s.Facets {
+		area += facetArea(f)
+	}
+	return area
+}
+
+func facetArea(f Facet) float64 {
+	return f.Vertices[1].Sub(f.Vertices[0]).Cross(f.Vertices[2].Sub(f.Vertices[0])).Length() / 2
+}
+
+// Centroid returns the center of mass of the volume enclosed by s, assuming
+// uniform density. If s is not watertight, it encloses no well defined
+// volume, and the centroid of its surface is returned instead.
+func (s *Solid) Centroid() vector.V3 {
+	if s.Watertight() {
+		// sum the centroids of the tetrahedra formed by each facet and
+		// the origin, weighted by their signed volumes
+		var sum vector.V3
+		var volume float64
+		for _, f := range s.Facets {
+			a, b, c := f.Vertices[0], f.Vertices[1], f.Vertices[2]
+			v := a.Dot(b.Cross(c)) / 6
+			sum = sum.Add(a.Add(b).Add(c).Mul(v / 4))
+			volume += v
+		}
+		return sum.Mul(1 / volume)
+	}
+
+	var sum vector.V3
+	var area float64
+	for _, f := range s.Facets {
+		a := facetArea(f)
+		sum = sum.Add(f.Vertices[0].Add(f.Vertices[1]).Add(f.Vertices[2]).Mul(a / 3))
+		area += a
+	}
+	if area == 0 {
+		min, max := s.Bounds()
+		return min.Add(max).Mul(0.5)
+	}
+	return sum.Mul(1 / area)
+}
+
+// Watertight reports whether s is a closed, consistently wound surface:
+// every edge must be shared by exactly two facets, which traverse it in
+// opposite directions. Vertices must match exactly, and degenerate
+// facets count as holes.
+func (s *Solid) Watertight() bool {
+	type edge struct{ from, to vector.V3 }
+	edges := make(map[edge]int, len(s.Facets)*3)
+	for _, f := range s.Facets {
+		for i, v := range f.Vertices {
+			w := f.Vertices[(i+1)%3]
+			if v == w {
+				return false
+			}
+			edges[edge{v, w}]++
+		}
+	}
+	for e, n := range edges {
+		if n != 1 || edges[edge{e.to, e.from}] != 1 {
+			return false
+		}
+	}
+	return true
+}
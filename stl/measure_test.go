@@ -0,0 +1,72 @@
+package stl
+
+import (
+	"math"
+	"os"
+	"testing"
+
+	"sigint.ca/slice/vector"
+)
+
+func TestMeasure(t *testing.T) {
+	tests := []struct {
+		file     string
+		volume   float64
+		area     float64
+		centroid vector.V3
+	}{
+		{"cube20_ascii.stl", 8000, 2400, vector.V3{X: 10, Y: 10, Z: 10}},
+		{"cube40_binary.stl", 64000, 9600, vector.V3{X: 20, Y: 20, Z: 20}},
+	}
+	for _, test := range tests {
+		f, err := os.Open("../testdata/" + test.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := Parse(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// measurements don't depend on where the solid is
+		min, _ := s.Bounds()
+		s.Translate(min.Mul(-1))
+
+		// the binary cube's coordinates are only float32
+		if v := s.Volume(); math.Abs(v-test.volume) > 1e-5*test.volume {
+			t.Errorf("%s: got volume %v, want %v", test.file, v, test.volume)
+		}
+		if a := s.SurfaceArea(); math.Abs(a-test.area) > 1e-5*test.area {
+			t.Errorf("%s: got surface area %v, want %v", test.file, a, test.area)
+		}
+		if c := s.Centroid(); c.Sub(test.centroid).Length() > 1e-5 {
+			t.Errorf("%s: got centroid %v, want %v", test.file, c, test.centroid)
+		}
+		if !s.Watertight() {
+			t.Errorf("%s: not watertight", test.file)
+		}
+
+		open := NewSolid(s.Facets[1:])
+		if open.Watertight() {
+			t.Errorf("%s: watertight with a facet missing", test.file)
+		}
+		flipped := NewSolid(append([]Facet(nil), s.Facets...))
+		flipped.Facets[0].Vertices[1], flipped.Facets[0].Vertices[2] = flipped.Facets[0].Vertices[2], flipped.Facets[0].Vertices[1]
+		if flipped.Watertight() {
+			t.Errorf("%s: watertight with a flipped facet", test.file)
+		}
+
+		// an open surface has the centroid of its area
+		var facets []Facet
+		for _, f := range s.Facets {
+			if f.Normal.Z > 0.5 {
+				facets = append(facets, f)
+			}
+		}
+		top := NewSolid(facets)
+		want := vector.V3{X: test.centroid.X, Y: test.centroid.Y, Z: 2 * test.centroid.Z}
+		if c := top.Centroid(); c.Sub(want).Length() > 1e-5 {
+			t.Errorf("%s: got centroid %v of top face, want %v", test.file, c, want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package stl
+
+import (
+	"fmt"
+	"math"
+)
+
+// A NormalPolicy determines how the facet normals stored in an STL are
+// reconciled with the normals implied by the winding order of the facets'
+// vertices (counter-clockwise when viewed from outside). Facets whose
+// stored normal is missing (zero) always get the winding normal, and
+// degenerate facets, which have no winding normal, get the stored one.
+type NormalPolicy int
+
+const (
+	// Repair reverses the vertex order of facets whose winding disagrees
+	// with their stored normal. This is the default.
+	Repair NormalPolicy = iota
+
+	// TrustWinding replaces stored normals with winding normals, leaving
+	// the vertices as they are.
+	TrustWinding
+
+	// TrustFile keeps stored normals, leaving the vertices as they are.
+	TrustFile
+)
+
+func (p NormalPolicy) String() string {
+	switch p {
+	case Repair:
+		return "repair"
+	case TrustWinding:
+		return "trust winding"
+	case TrustFile:
+		return "trust file"
+	}
+	return fmt.Sprintf("NormalPolicy(%d)", int(p))
+}
+
+// ReconcileNormals reconciles the normals of s with the winding of their
+// vertices according to p, treating the current normals as the stored ones,
+// and records the facets whose normals disagree in s.NormalMismatches.
+// STLs are reconciled as they are parsed; this is for solids from elsewhere.
+func (s *Solid) ReconcileNormals(p NormalPolicy) {
+	s.NormalMismatches = nil
+	for i := range s.Facets {
+		if p.fix(&s.Facets[i]) {
+			s.NormalMismatches = append(s.NormalMismatches, i)
+		}
+	}
+}
+
+// fix reconciles the normal of f, which must be the stored normal,
+// according to p. It returns true if the stored normal disagreed
+// with the winding.
+func (p NormalPolicy) fix(f *Facet) bool {
+	stored := f.Normal
+	f.calculateNormal()
+	winding := f.Normal
+
+	storedOK := stored.Length() > 1e-6
+	windingOK := !math.IsNaN(winding.X)
+	if !storedOK {
+		return false
+	}
+	if !windingOK {
+		f.Normal = stored.Normalize()
+		return false
+	}
+
+	agree := stored.Dot(winding) > 0
+	switch {
+	case p == TrustFile:
+		f.Normal = stored.Normalize()
+	case p == Repair && !agree:
+		f.Vertices[1], f.Vertices[2] = f.Vertices[2], f.Vertices[1]
+		f.calculateNormal()
+	}
+	return !agree
+}
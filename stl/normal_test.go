@@ -0,0 +1,102 @@
+package stl
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"sigint.ca/slice/vector"
+)
+
+// flipped has two facets with stored normals pointing up; the
+// second is wound clockwise, so its winding normal points down.
+const flipped = `solid flipped
+facet normal 0 0 1
+ outer loop
+  vertex 0 0 0
+  vertex 1 0 0
+  vertex 0 1 0
+ endloop
+endfacet
+facet normal 0 0 1
+ outer loop
+  vertex 1 1 0
+  vertex 1 0 0
+  vertex 0 1 0
+ endloop
+endfacet
+endsolid flipped
+`
+
+func TestNormalPolicies(t *testing.T) {
+	up := vector.V3{Z: 1}
+	down := vector.V3{Z: -1}
+	tests := []struct {
+		policy  NormalPolicy
+		normals [2]vector.V3
+		swapped bool // whether the vertices of the second facet were reordered
+	}{
+		{policy: Repair, normals: [2]vector.V3{up, up}, swapped: true},
+		{policy: TrustWinding, normals: [2]vector.V3{up, down}},
+		{policy: TrustFile, normals: [2]vector.V3{up, up}},
+	}
+
+	for _, test := range tests {
+		d := Decoder{Normals: test.policy}
+		s, err := d.Parse(strings.NewReader(flipped))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(s.NormalMismatches) != "[1]" {
+			t.Errorf("%v: mismatches=%v, want [1]", test.policy, s.NormalMismatches)
+		}
+		for i, f := range s.Facets {
+			if f.Normal.Sub(test.normals[i]).Length() > 1e-9 {
+				t.Errorf("%v: facet %d: normal=%v, want %v", test.policy, i, f.Normal, test.normals[i])
+			}
+		}
+		swapped := s.Facets[1].Vertices[1] == (vector.V3{Y: 1})
+		if swapped != test.swapped {
+			t.Errorf("%v: swapped=%v, want %v", test.policy, swapped, test.swapped)
+		}
+	}
+}
+
+func TestStoredNormals(t *testing.T) {
+	f, err := os.Open("../testdata/pikachu.stl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := Parse(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(want.NormalMismatches) != 0 {
+		t.Errorf("%d mismatched normals in pikachu.stl", len(want.NormalMismatches))
+	}
+
+	// normals written to a file should come back as they were, even
+	// when they aren't what the winding implies
+	for i := range want.Facets {
+		want.Facets[i].Normal = vector.V3{X: 1}
+	}
+	d := Decoder{Normals: TrustFile}
+	for _, format := range []Format{ASCII, Binary} {
+		var buf bytes.Buffer
+		if err := Encode(&buf, want, format); err != nil {
+			t.Fatal(err)
+		}
+		got, err := d.Parse(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, f := range got.Facets {
+			if f.Normal != want.Facets[i].Normal {
+				t.Fatalf("%v: facet %d: normal=%v, want %v", format, i, f.Normal, want.Facets[i].Normal)
+			}
+		}
+	}
+}
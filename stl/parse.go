@@ -1,48 +1,194 @@
 package stl
 
 import (
-	"bufio"
+	"bytes"
+	"encoding/binary"
 	"fmt"
 	"io"
 
 	"sigint.ca/slice/vector"
 )
 
-type facetReader interface {
-	readFacets() ([]Facet, error)
-}
-
 type Facet struct {
 	Vertices [3]vector.V3
 	Normal   vector.V3
+	Attr     uint16 // attribute byte count field of binary STLs
 }
 
 func (f Facet) String() string {
 	return fmt.Sprintf("n=%v v=%v\n", f.Normal, f.Vertices)
 }
 
-// Parse parses a new STL from an io.Reader.
+// A Decoder parses STLs. The zero value is ready to use, and is
+// what Parse and ParseAll use.
+type Decoder struct {
+	// Normals determines how stored facet normals are reconciled
+	// with vertex winding.
+	Normals NormalPolicy
+
+	// If Parallel is set, binary STLs are read in one go and decoded by
+	// several goroutines. This is faster for large files, but needs room
+	// for the raw file in memory as well as the decoded facets.
+	Parallel bool
+}
+
+// Parse parses a new STL from an io.Reader. If the input contains
+// several solids, their facets are combined into one Solid.
 func Parse(r io.Reader) (*Solid, error) {
-	bufr := bufio.NewReader(r)
-	top, err := bufr.Peek(6)
+	return new(Decoder).Parse(r)
+}
+
+// ParseAll parses every solid in an STL from an io.Reader. ASCII STLs may
+// contain any number of solids; binary STLs always contain exactly one.
+func ParseAll(r io.Reader) ([]*Solid, error) {
+	return new(Decoder).ParseAll(r)
+}
+
+// Parse is like the package function Parse, using d's options.
+func (d *Decoder) Parse(r io.Reader) (*Solid, error) {
+	solids, err := d.ParseAll(r)
 	if err != nil {
 		return nil, err
 	}
-	var fr facetReader
-	if string(top) == "solid " {
-		fr = &asciiReader{bufr}
-	} else {
-		fr = &binaryReader{bufr}
+	if len(solids) == 1 {
+		return solids[0], nil
+	}
+	facets := make([]Facet, 0)
+	var mismatches []int
+	for _, s := range solids {
+		for _, i := range s.NormalMismatches {
+			mismatches = append(mismatches, len(facets)+i)
+		}
+		facets = append(facets, s.Facets...)
 	}
-	facets, err := fr.readFacets()
+	s := NewSolid(facets)
+	s.Name = solids[0].Name
+	s.NormalMismatches = mismatches
+	return s, nil
+}
+
+// ParseAll is like the package function ParseAll, using d's options.
+func (d *Decoder) ParseAll(r io.Reader) ([]*Solid, error) {
+	rd, err := NewReader(r)
 	if err != nil {
 		return nil, err
 	}
+	rd.Normals = d.Normals
 
-	s := &Solid{Facets: facets}
-	s.updateBounds()
+	if d.Parallel && rd.Format() == Binary {
+		facets, mismatches, err := rd.binary.readAll(d.Normals)
+		if err != nil {
+			return nil, err
+		}
+		s := NewSolid(facets)
+		s.Header = rd.Header()
+		s.NormalMismatches = mismatches
+		return []*Solid{s}, nil
+	}
 
-	return s, nil
+	facets := make([]Facet, 0, rd.sizeHint())
+	starts := make([]int, 0) // index of the first facet of each ASCII solid
+	for {
+		f, err := rd.Next()
+		for len(starts) < len(rd.Names()) {
+			starts = append(starts, len(facets))
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		facets = append(facets, f)
+	}
+
+	if rd.Format() == Binary {
+		s := NewSolid(facets)
+		s.Header = rd.Header()
+		s.NormalMismatches = rd.NormalMismatches()
+		return []*Solid{s}, nil
+	}
+
+	// split the facets up into their solids
+	names := rd.Names()
+	solids := make([]*Solid, len(names))
+	mismatches := rd.NormalMismatches()
+	for i, name := range names {
+		start, end := starts[i], len(facets)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		s := NewSolid(facets[start:end:end])
+		s.Name = name
+		for len(mismatches) > 0 && mismatches[0] < end {
+			s.NormalMismatches = append(s.NormalMismatches, mismatches[0]-start)
+			mismatches = mismatches[1:]
+		}
+		solids[i] = s
+	}
+	return solids, nil
+}
+
+// detect guesses the format of an STL from its first few hundred bytes
+// and its size, which is negative if unknown.
+//
+// ASCII STLs start with "solid", but so do the headers of many binary STLs,
+// so that alone isn't enough to go on. A binary STL's facet count must agree
+// with its size, and an ASCII STL's "solid" line must be followed by a facet
+// or the end of the solid. Failing both of those, text is assumed to be ASCII.
+func detect(top []byte, size int64) Format {
+	if !hasKeyword(bytes.TrimLeft(top, " \t\r\n"), "solid") {
+		return Binary
+	}
+	if size >= 84 && len(top) >= 84 {
+		n := int64(binary.LittleEndian.Uint32(top[80:84]))
+		if 84+50*n == size {
+			return Binary
+		}
+	}
+	if i := bytes.IndexByte(top, '\n'); i >= 0 {
+		next := bytes.TrimLeft(top[i+1:], " \t\r\n")
+		if hasKeyword(next, "facet") || hasKeyword(next, "endsolid") {
+			return ASCII
+		}
+	}
+	for _, c := range top {
+		if c < ' ' && !isSpace(c) || c == 0x7f {
+			return Binary
+		}
+	}
+	return ASCII
+}
+
+// hasKeyword reports whether b starts with keyword, ignoring case,
+// followed by whitespace or nothing.
+func hasKeyword(b []byte, keyword string) bool {
+	if len(b) < len(keyword) || !bytes.EqualFold(b[:len(keyword)], []byte(keyword)) {
+		return false
+	}
+	return len(b) == len(keyword) || isSpace(b[len(keyword)])
+}
+
+// streamSize returns the number of bytes remaining in r,
+// or -1 if that can't be determined without reading it.
+func streamSize(r io.Reader) int64 {
+	switch r := r.(type) {
+	case interface{ Len() int }:
+		return int64(r.Len())
+	case io.Seeker:
+		cur, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		end, err := r.Seek(0, io.SeekEnd)
+		if err != nil {
+			return -1
+		}
+		if _, err := r.Seek(cur, io.SeekStart); err != nil {
+			return -1
+		}
+		return end - cur
+	}
+	return -1
 }
 
 func (f *Facet) calculateNormal() {
@@ -2,9 +2,12 @@ package stl
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -196,12 +199,207 @@ func BenchmarkParse3DBenchy(b *testing.B) {
 		b.Fatal(err)
 	}
 
-	for i := 0; i < b.N; i++ {
-		r := bytes.NewBuffer(buf)
-		s, err := Parse(r)
+	decoders := []struct {
+		name string
+		d    Decoder
+	}{
+		{name: "serial", d: Decoder{}},
+		{name: "parallel", d: Decoder{Parallel: true}},
+	}
+	for _, dec := range decoders {
+		b.Run(dec.name, func(b *testing.B) {
+			b.SetBytes(int64(len(buf)))
+			for i := 0; i < b.N; i++ {
+				r := bytes.NewBuffer(buf)
+				s, err := dec.d.Parse(r)
+				if err != nil {
+					b.Fatal(err)
+				}
+				Sink = s
+			}
+		})
+	}
+}
+
+const asciiFacet = `facet normal 0 0 1
+ outer loop
+  vertex 0 0 0
+  vertex 1 0 0
+  vertex 0 1 0
+ endloop
+endfacet
+`
+
+func TestParseASCIIVariants(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		names []string // names of the solids
+	}{
+		{
+			name:  "plain",
+			input: "solid cube\n" + asciiFacet + "endsolid cube\n",
+			names: []string{"cube"},
+		},
+		{
+			name:  "no name",
+			input: "solid\n" + asciiFacet + asciiFacet + "endsolid\n",
+			names: []string{""},
+		},
+		{
+			name:  "name with spaces",
+			input: "solid my part v2\n" + asciiFacet + "endsolid my part v2",
+			names: []string{"my part v2"},
+		},
+		{
+			name:  "crlf and tabs",
+			input: strings.NewReplacer("\n", "\r\n", " ", "\t").Replace("solid x\n" + asciiFacet + "endsolid x\n"),
+			names: []string{"x"},
+		},
+		{
+			name:  "uppercase",
+			input: strings.ToUpper("solid x\n" + asciiFacet + "endsolid x\n"),
+			names: []string{"X"},
+		},
+		{
+			name:  "blank lines",
+			input: "\n\nsolid x\n\n" + strings.Replace(asciiFacet, "\n", "\n\n", -1) + "\nendsolid x\n\n",
+			names: []string{"x"},
+		},
+		{
+			name:  "one line",
+			input: "solid x\n" + strings.Replace(asciiFacet, "\n", " ", -1) + "endsolid",
+			names: []string{"x"},
+		},
+		{
+			name: "multiple solids",
+			input: "solid a\n" + asciiFacet + "endsolid a\n" +
+				"solid b\n" + asciiFacet + asciiFacet + "endsolid b\n",
+			names: []string{"a", "b"},
+		},
+		{
+			name:  "empty solid",
+			input: "solid empty\nendsolid empty\n",
+			names: []string{"empty"},
+		},
+	}
+
+	for _, test := range tests {
+		solids, err := ParseAll(strings.NewReader(test.input))
 		if err != nil {
-			b.Fatal(err)
+			t.Errorf("%s: %v", test.name, err)
+			continue
 		}
-		Sink = s
+		var names []string
+		for _, s := range solids {
+			names = append(names, s.Name)
+		}
+		if fmt.Sprint(names) != fmt.Sprint(test.names) {
+			t.Errorf("%s: got solids %q, want %q", test.name, names, test.names)
+		}
+
+		s, err := Parse(strings.NewReader(test.input))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		nfacets := strings.Count(strings.ToLower(test.input), "endfacet")
+		if len(s.Facets) != nfacets {
+			t.Errorf("%s: got %d facets, want %d", test.name, len(s.Facets), nfacets)
+		}
+	}
+}
+
+func TestParseASCIIErrors(t *testing.T) {
+	tests := []struct {
+		input     string
+		line, col int
+	}{
+		{input: "solid x\nfacet normal 0 0 1\n  outer lop\n", line: 3, col: 9},
+		{input: "solid x\nfacet normal 0 0 1\nouter loop\nvertex 0 0 zero\n", line: 4, col: 12},
+		{input: "solid x\n" + asciiFacet, line: 9, col: 1},
+		{input: "solid x\nendsolid x\nbogus\n", line: 3, col: 1},
+	}
+
+	for _, test := range tests {
+		_, err := Parse(strings.NewReader(test.input))
+		var serr *SyntaxError
+		if !errors.As(err, &serr) {
+			t.Errorf("%q: got error %v, want syntax error", test.input, err)
+			continue
+		}
+		if serr.Line != test.line || serr.Col != test.col {
+			t.Errorf("%q: error at %d:%d, want %d:%d (%v)", test.input, serr.Line, serr.Col, test.line, test.col, err)
+		}
+	}
+}
+
+// opaqueReader hides the size of the underlying reader.
+type opaqueReader struct {
+	r io.Reader
+}
+
+func (r opaqueReader) Read(p []byte) (int, error) { return r.r.Read(p) }
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		path   string
+		format Format
+	}{
+		{path: "../testdata/cube20_ascii.stl", format: ASCII},
+		{path: "../testdata/cube20_ascii_crlf.stl", format: ASCII},
+		{path: "../testdata/cube40_binary.stl", format: Binary},
+		{path: "../testdata/pikachu.stl", format: Binary},
+		// binary STLs whose headers start with "solid"
+		{path: "../testdata/cube20_solid_header.stl", format: Binary},
+		{path: "../testdata/cube20_solid_newline.stl", format: Binary},
+	}
+
+	for _, test := range tests {
+		buf, err := ioutil.ReadFile(test.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		top := buf
+		if len(top) > 512 {
+			top = top[:512]
+		}
+
+		// with and without knowing the size
+		if f := detect(top, int64(len(buf))); f != test.format {
+			t.Errorf("%s: detected %v, want %v", test.path, f, test.format)
+		}
+		if f := detect(top, -1); f != test.format {
+			t.Errorf("%s: detected %v without size, want %v", test.path, f, test.format)
+		}
+
+		readers := map[string]io.Reader{
+			"bytes":  bytes.NewReader(buf),
+			"opaque": opaqueReader{bytes.NewReader(buf)},
+		}
+		for name, r := range readers {
+			s, err := Parse(r)
+			if err != nil {
+				t.Errorf("%s (%s reader): %v", test.path, name, err)
+				continue
+			}
+			if len(s.Facets) == 0 {
+				t.Errorf("%s (%s reader): no facets", test.path, name)
+			}
+		}
+	}
+
+	f, err := os.Open("../testdata/cube20_solid_header.stl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	s, err := Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	min, max := s.Bounds()
+	if min.String() != "(0.0, -20.0, 0.0)" || max.String() != "(20.0, 0.0, 20.0)" {
+		t.Errorf("bad bounds: %v-%v", min, max)
 	}
 }
@@ -0,0 +1,106 @@
+package stl
+
+import (
+	"math"
+
+	"sigint.ca/slice/vector"
+)
+
+// Triangulate splits a simple planar polygon into triangles by ear clipping,
+// returning them as indices into poly. The triangles keep the winding order
+// of the polygon, which may be concave. If poly is too degenerate to clip,
+// whatever remains is triangulated as a fan.
+func Triangulate(poly []vector.V3) [][3]int {
+	n := len(poly)
+	if n < 3 {
+		return nil
+	}
+	tris := make([][3]int, 0, n-2)
+	if n == 3 {
+		return append(tris, [3]int{0, 1, 2})
+	}
+
+	// project onto the plane of the polygon's dominant axis, keeping the
+	// remaining axes in cyclic order so that orientation is preserved
+	normal := polygonNormal(poly)
+	ax, ay, az := math.Abs(normal.X), math.Abs(normal.Y), math.Abs(normal.Z)
+	pts := make([]vector.V2, n)
+	for i, v := range poly {
+		switch {
+		case ax >= ay && ax >= az:
+			pts[i] = vector.V2{X: v.Y, Y: v.Z}
+		case ay >= az:
+			pts[i] = vector.V2{X: v.Z, Y: v.X}
+		default:
+			pts[i] = vector.V2{X: v.X, Y: v.Y}
+		}
+	}
+	var area float64
+	for i := range pts {
+		j := (i + 1) % n
+		area += pts[i].X*pts[j].Y - pts[j].X*pts[i].Y
+	}
+	sign := 1.0
+	if area < 0 {
+		sign = -1
+	}
+
+	idx := make([]int, n)
+	for i := range idx {
+		idx[i] = i
+	}
+	for len(idx) > 3 {
+		m := len(idx)
+		clipped := false
+		for i := range idx {
+			a, b, c := idx[(i+m-1)%m], idx[i], idx[(i+1)%m]
+			if sign*cross2(pts[a], pts[b], pts[c]) <= 0 {
+				continue // reflex or degenerate corner
+			}
+			ear := true
+			for _, j := range idx {
+				if j != a && j != b && j != c && inTriangle(pts[j], pts[a], pts[b], pts[c], sign) {
+					ear = false
+					break
+				}
+			}
+			if ear {
+				tris = append(tris, [3]int{a, b, c})
+				idx = append(idx[:i], idx[i+1:]...)
+				clipped = true
+				break
+			}
+		}
+		if !clipped {
+			for i := 1; i+1 < len(idx); i++ {
+				tris = append(tris, [3]int{idx[0], idx[i], idx[i+1]})
+			}
+			return tris
+		}
+	}
+	return append(tris, [3]int{idx[0], idx[1], idx[2]})
+}
+
+// polygonNormal returns the (unnormalized) normal of poly by Newell's method,
+// which is robust for concave and slightly non-planar polygons.
+func polygonNormal(poly []vector.V3) vector.V3 {
+	var n vector.V3
+	for i, v := range poly {
+		w := poly[(i+1)%len(poly)]
+		n.X += (v.Y - w.Y) * (v.Z + w.Z)
+		n.Y += (v.Z - w.Z) * (v.X + w.X)
+		n.Z += (v.X - w.X) * (v.Y + w.Y)
+	}
+	return n
+}
+
+// cross2 returns the z component of (b-a)x(c-b).
+func cross2(a, b, c vector.V2) float64 {
+	return (b.X-a.X)*(c.Y-b.Y) - (b.Y-a.Y)*(c.X-b.X)
+}
+
+// inTriangle reports whether p lies inside or on the triangle abc,
+// whose orientation is given by sign.
+func inTriangle(p, a, b, c vector.V2, sign float64) bool {
+	return sign*cross2(a, b, p) >= 0 && sign*cross2(b, c, p) >= 0 && sign*cross2(c, a, p) >= 0
+}
@@ -0,0 +1,127 @@
+package stl
+
+import (
+	"bufio"
+	"io"
+
+	"sigint.ca/slice/vector"
+)
+
+type facetReader interface {
+	next() (Facet, error)
+}
+
+// A Reader reads the facets of an STL one at a time, so that files too
+// large to hold in memory can be processed.
+type Reader struct {
+	// Normals determines how stored facet normals are reconciled
+	// with vertex winding.
+	Normals NormalPolicy
+
+	fr         facetReader
+	format     Format
+	ascii      *asciiReader  // if format is ASCII
+	binary     *binaryReader // if format is Binary
+	n          int           // number of facets read
+	mismatches []int
+}
+
+// NewReader returns a Reader which reads from r. It reads enough of r to
+// determine the STL's format, and the header of binary STLs.
+func NewReader(r io.Reader) (*Reader, error) {
+	size := streamSize(r)
+	bufr := bufio.NewReader(r)
+	top, err := bufr.Peek(512)
+	if err != nil && len(top) == 0 {
+		return nil, err
+	}
+
+	rd := &Reader{format: detect(top, size)}
+	if rd.format == ASCII {
+		rd.ascii = newASCIIReader(bufr)
+		rd.fr = rd.ascii
+	} else {
+		rd.binary, err = newBinaryReader(bufr, size)
+		if err != nil {
+			return nil, err
+		}
+		rd.fr = rd.binary
+	}
+	return rd, nil
+}
+
+// Next returns the next facet. It returns io.EOF when there are no more.
+func (r *Reader) Next() (Facet, error) {
+	f, err := r.fr.next()
+	if err != nil {
+		return Facet{}, err
+	}
+	if r.Normals.fix(&f) {
+		r.mismatches = append(r.mismatches, r.n)
+	}
+	r.n++
+	return f, nil
+}
+
+// Format returns the format of the STL.
+func (r *Reader) Format() Format {
+	return r.format
+}
+
+// Header returns the 80 byte header of a binary STL, or nil for ASCII.
+func (r *Reader) Header() []byte {
+	if r.binary == nil {
+		return nil
+	}
+	return r.binary.header
+}
+
+// Names returns the names of the solids started so far in an ASCII STL.
+// The last facet returned by Next belongs to the last of them.
+func (r *Reader) Names() []string {
+	if r.ascii == nil {
+		return nil
+	}
+	return r.ascii.names
+}
+
+// NormalMismatches returns the indices of the facets read so far whose stored
+// normal pointed against their vertex winding.
+func (r *Reader) NormalMismatches() []int {
+	return r.mismatches
+}
+
+// sizeHint returns a guess at the number of facets, for preallocation.
+// Counts from binary headers are only trusted up to a point, since they
+// may be wrong when the size of the input is unknown.
+func (r *Reader) sizeHint() int {
+	const limit = 1 << 16
+	if r.binary == nil {
+		return 0
+	}
+	if r.binary.count > limit {
+		return limit
+	}
+	return int(r.binary.count)
+}
+
+// Bounds returns the bounding box of the STL read from r, without holding
+// more than one facet in memory at a time.
+func Bounds(r io.Reader) (min, max vector.V3, err error) {
+	rd, err := NewReader(r)
+	if err != nil {
+		return min, max, err
+	}
+	min, max = emptyBounds()
+	for {
+		f, err := rd.fr.next() // skip normal processing
+		if err == io.EOF {
+			return min, max, nil
+		} else if err != nil {
+			return min, max, err
+		}
+		for _, v := range f.Vertices {
+			growBounds(&min, &max, v)
+		}
+	}
+}
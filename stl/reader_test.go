@@ -0,0 +1,163 @@
+package stl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestReader(t *testing.T) {
+	f, err := os.Open("../testdata/cube40_binary.stl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	r, err := NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Format() != Binary || len(r.Header()) != 80 {
+		t.Errorf("format=%v, header length=%d", r.Format(), len(r.Header()))
+	}
+	var n int
+	for {
+		_, err := r.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		n++
+	}
+	if n != 12 {
+		t.Errorf("read %d facets, want 12", n)
+	}
+
+	input := "solid a\n" + asciiFacet + "endsolid a\nsolid b\n" + asciiFacet + "endsolid b\n"
+	r, err = NewReader(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"a", "b"} {
+		if _, err := r.Next(); err != nil {
+			t.Fatal(err)
+		}
+		names := r.Names()
+		if names[len(names)-1] != want {
+			t.Errorf("facet in solid %q, want %q", names[len(names)-1], want)
+		}
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("got %v after last facet, want EOF", err)
+	}
+}
+
+func TestBounds(t *testing.T) {
+	paths := []string{
+		"../testdata/cube20_ascii.stl",
+		"../testdata/cube40_binary.stl",
+		"../testdata/pikachu.stl",
+	}
+	for _, path := range paths {
+		buf, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := Parse(bytes.NewReader(buf))
+		if err != nil {
+			t.Fatal(err)
+		}
+		min, max, err := Bounds(bytes.NewReader(buf))
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantMin, wantMax := s.Bounds()
+		if min != wantMin || max != wantMax {
+			t.Errorf("%s: bounds=%v-%v, want %v-%v", path, min, max, wantMin, wantMax)
+		}
+	}
+}
+
+func TestBadFacetCount(t *testing.T) {
+	buf, err := ioutil.ReadFile("../testdata/cube40_binary.stl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	binary.LittleEndian.PutUint32(buf[80:84], 1<<31)
+
+	// with the size known, the count is rejected up front
+	if _, err := NewReader(bytes.NewReader(buf)); err == nil {
+		t.Error("absurd facet count accepted")
+	}
+
+	// otherwise, parsing fails when the data runs out,
+	// without having allocated room for all those facets
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	if _, err := Parse(opaqueReader{bytes.NewReader(buf)}); err == nil {
+		t.Error("truncated STL accepted")
+	}
+	runtime.ReadMemStats(&after)
+	if n := after.TotalAlloc - before.TotalAlloc; n > 16<<20 {
+		t.Errorf("allocated %d bytes parsing a 12 facet STL", n)
+	}
+}
+
+func TestParallel(t *testing.T) {
+	f, err := os.Open("../testdata/pikachu.stl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := Parse(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// make it big enough to be split up, and flip some normals
+	var facets []Facet
+	for len(facets) < 10000 {
+		facets = append(facets, s.Facets...)
+	}
+	for i := 0; i < len(facets); i += 7 {
+		facets[i].Normal = facets[i].Normal.Mul(-1)
+	}
+	var buf bytes.Buffer
+	if err := WriteBinary(&buf, NewSolid(facets)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, policy := range []NormalPolicy{Repair, TrustWinding, TrustFile} {
+		serial, err := (&Decoder{Normals: policy}).Parse(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range []io.Reader{bytes.NewReader(buf.Bytes()), opaqueReader{bytes.NewReader(buf.Bytes())}} {
+			parallel, err := (&Decoder{Normals: policy, Parallel: true}).Parse(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(parallel.Facets) != len(serial.Facets) {
+				t.Fatalf("%v: got %d facets, want %d", policy, len(parallel.Facets), len(serial.Facets))
+			}
+			for i := range serial.Facets {
+				if parallel.Facets[i] != serial.Facets[i] {
+					t.Fatalf("%v: facet %d: got %v, want %v", policy, i, parallel.Facets[i], serial.Facets[i])
+				}
+			}
+			if fmt.Sprint(parallel.NormalMismatches) != fmt.Sprint(serial.NormalMismatches) {
+				t.Errorf("%v: mismatches differ", policy)
+			}
+			if len(serial.NormalMismatches) == 0 {
+				t.Errorf("%v: no mismatches found", policy)
+			}
+		}
+	}
+}
@@ -7,40 +7,62 @@ import (
 )
 
 type Solid struct {
+	Name   string // name given in ASCII STLs
+	Header []byte // 80 byte header of binary STLs
 	Facets []Facet
 
+	// NormalMismatches lists the indices of the facets whose stored
+	// normal pointed against their vertex winding when parsed.
+	NormalMismatches []int
+
 	min, max vector.V3
 }
 
+// NewSolid returns a new Solid made up of facets.
+func NewSolid(facets []Facet) *Solid {
+	s := &Solid{Facets: facets}
+	s.updateBounds()
+	return s
+}
+
 func (s *Solid) Bounds() (min, max vector.V3) {
 	return s.min, s.max
 }
 
 func (s *Solid) updateBounds() {
-	small := math.Inf(-1)
-	big := math.Inf(+1)
-	s.min, s.max = vector.V3{big, big, big}, vector.V3{small, small, small}
-
+	s.min, s.max = emptyBounds()
 	for _, f := range s.Facets {
 		for _, v := range f.Vertices {
-			if v.X < s.min.X {
-				s.min.X = v.X
-			}
-			if v.X > s.max.X {
-				s.max.X = v.X
-			}
-			if v.Y < s.min.Y {
-				s.min.Y = v.Y
-			}
-			if v.Y > s.max.Y {
-				s.max.Y = v.Y
-			}
-			if v.Z < s.min.Z {
-				s.min.Z = v.Z
-			}
-			if v.Z > s.max.Z {
-				s.max.Z = v.Z
-			}
+			growBounds(&s.min, &s.max, v)
 		}
 	}
 }
+
+// emptyBounds returns inverted bounds, which any vertex will grow.
+func emptyBounds() (min, max vector.V3) {
+	small := math.Inf(-1)
+	big := math.Inf(+1)
+	return vector.V3{X: big, Y: big, Z: big}, vector.V3{X: small, Y: small, Z: small}
+}
+
+// growBounds expands min and max to include v.
+func growBounds(min, max *vector.V3, v vector.V3) {
+	if v.X < min.X {
+		min.X = v.X
+	}
+	if v.X > max.X {
+		max.X = v.X
+	}
+	if v.Y < min.Y {
+		min.Y = v.Y
+	}
+	if v.Y > max.Y {
+		max.Y = v.Y
+	}
+	if v.Z < min.Z {
+		min.Z = v.Z
+	}
+	if v.Z > max.Z {
+		max.Z = v.Z
+	}
+}
@@ -0,0 +1,58 @@
+package stl
+
+import (
+	"math"
+
+	"sigint.ca/slice/vector"
+)
+
+// Transform applies m to the vertices of s, recomputing their normals and
+// the bounds of s. If m mirrors, the vertex order of each facet is reversed
+// so that the facets stay wound counter-clockwise when viewed from outside.
+func (s *Solid) Transform(m vector.M4) {
+	mirror := m.Det() < 0
+	for i := range s.Facets {
+		f := &s.Facets[i]
+		for j, v := range f.Vertices {
+			f.Vertices[j] = m.Apply(v)
+		}
+		if mirror {
+			f.Vertices[1], f.Vertices[2] = f.Vertices[2], f.Vertices[1]
+		}
+		stored := f.Normal
+		f.calculateNormal()
+		if math.IsNaN(f.Normal.X) {
+			// degenerate facets keep an approximation of their old normal
+			f.Normal = m.ApplyDir(stored).Normalize()
+		}
+	}
+	s.updateBounds()
+}
+
+// Translate moves s by d.
+func (s *Solid) Translate(d vector.V3) {
+	s.Transform(vector.Translation(d))
+}
+
+// Rotate rotates s by angle radians about the line through origin in the
+// direction of axis, counter-clockwise when looking back along the axis.
+func (s *Solid) Rotate(origin, axis vector.V3, angle float64) {
+	s.Transform(vector.Translation(origin).
+		Mul(vector.Rotation(axis, angle)).
+		Mul(vector.Translation(origin.Mul(-1))))
+}
+
+// Scale scales s about origin by the factors in f along each axis.
+// Negative factors mirror s.
+func (s *Solid) Scale(origin, f vector.V3) {
+	s.Transform(vector.Translation(origin).
+		Mul(vector.Scaling(f)).
+		Mul(vector.Translation(origin.Mul(-1))))
+}
+
+// Mirror reflects s in the plane through origin with the given normal.
+func (s *Solid) Mirror(origin, normal vector.V3) {
+	s.Transform(vector.Translation(origin).
+		Mul(vector.Reflection(normal)).
+		Mul(vector.Translation(origin.Mul(-1))))
+}
@@ -0,0 +1,100 @@
+package stl
+
+import (
+	"math"
+	"os"
+	"testing"
+
+	"sigint.ca/slice/vector"
+)
+
+func near(a, b vector.V3) bool {
+	return a.Sub(b).Length() < 1e-9
+}
+
+func TestTransform(t *testing.T) {
+	f, err := os.Open("../testdata/cube20_ascii.stl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	cube, err := Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	min, max := cube.Bounds()
+	mid := min.Add(max).Mul(0.5)
+	copyCube := func() *Solid {
+		return NewSolid(append([]Facet(nil), cube.Facets...))
+	}
+
+	tests := []struct {
+		name     string
+		fn       func(*Solid)
+		min, max vector.V3
+	}{
+		{
+			"translate",
+			func(s *Solid) { s.Translate(vector.V3{X: 1, Y: -2, Z: 3}) },
+			min.Add(vector.V3{X: 1, Y: -2, Z: 3}), max.Add(vector.V3{X: 1, Y: -2, Z: 3}),
+		},
+		{
+			"rotate",
+			func(s *Solid) { s.Rotate(mid, vector.V3{X: 1, Y: 1, Z: 0}, math.Pi) },
+			min, max,
+		},
+		{
+			"rotate about origin",
+			func(s *Solid) { s.Rotate(vector.V3{}, vector.V3{Z: 1}, math.Pi/2) },
+			vector.V3{X: -max.Y, Y: min.X, Z: min.Z}, vector.V3{X: -min.Y, Y: max.X, Z: max.Z},
+		},
+		{
+			"scale",
+			func(s *Solid) { s.Scale(min, vector.V3{X: 2, Y: 1, Z: 0.5}) },
+			min, vector.V3{X: min.X + 2*(max.X-min.X), Y: max.Y, Z: min.Z + 0.5*(max.Z-min.Z)},
+		},
+		{
+			"scale negative",
+			func(s *Solid) { s.Scale(vector.V3{}, vector.V3{X: -1, Y: 1, Z: 1}) },
+			vector.V3{X: -max.X, Y: min.Y, Z: min.Z}, vector.V3{X: -min.X, Y: max.Y, Z: max.Z},
+		},
+		{
+			"mirror",
+			func(s *Solid) { s.Mirror(vector.V3{Z: -1}, vector.V3{Z: 1}) },
+			vector.V3{X: min.X, Y: min.Y, Z: -2 - max.Z}, vector.V3{X: max.X, Y: max.Y, Z: -2 - min.Z},
+		},
+	}
+	for _, test := range tests {
+		s := copyCube()
+		test.fn(s)
+		gotMin, gotMax := s.Bounds()
+		if !near(gotMin, test.min) || !near(gotMax, test.max) {
+			t.Errorf("%s: got bounds %v-%v, want %v-%v", test.name, gotMin, gotMax, test.min, test.max)
+		}
+
+		// the normals of a box point away from its middle
+		mid := gotMin.Add(gotMax).Mul(0.5)
+		for i, f := range s.Facets {
+			c := f.Vertices[0].Add(f.Vertices[1]).Add(f.Vertices[2]).Mul(1.0 / 3)
+			if f.Normal.Dot(c.Sub(mid)) <= 0 {
+				t.Errorf("%s: facet %d: normal %v points inwards", test.name, i, f.Normal)
+				break
+			}
+			var w Facet
+			w.Vertices = f.Vertices
+			w.calculateNormal()
+			if !near(w.Normal, f.Normal) {
+				t.Errorf("%s: facet %d: normal %v disagrees with winding %v", test.name, i, f.Normal, w.Normal)
+				break
+			}
+		}
+	}
+
+	// transforms compose
+	s := copyCube()
+	m := vector.Translation(vector.V3{Z: 5}).Mul(vector.Scaling(vector.V3{X: 2, Y: 2, Z: 2}))
+	s.Transform(m)
+	if gotMin, _ := s.Bounds(); !near(gotMin, min.Mul(2).Add(vector.V3{Z: 5})) {
+		t.Errorf("scale then translate: got min %v", gotMin)
+	}
+}
@@ -0,0 +1,115 @@
+package stl
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"sigint.ca/slice/vector"
+)
+
+// A Format is an STL encoding.
+type Format int
+
+const (
+	Binary Format = iota
+	ASCII
+)
+
+func (f Format) String() string {
+	switch f {
+	case Binary:
+		return "binary"
+	case ASCII:
+		return "ascii"
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// Encode writes s to w in the given format.
+func Encode(w io.Writer, s *Solid, f Format) error {
+	switch f {
+	case Binary:
+		return WriteBinary(w, s)
+	case ASCII:
+		return WriteASCII(w, s)
+	}
+	return fmt.Errorf("encode STL: unknown format %v", f)
+}
+
+// WriteASCII writes s to w in ASCII STL format. Coordinates are written with
+// just enough precision to be read back exactly as 32-bit floats.
+func WriteASCII(w io.Writer, s *Solid) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "solid %s\n", s.Name)
+	for _, f := range s.Facets {
+		fmt.Fprintf(bw, "  facet normal %s\n", formatV3(f.Normal))
+		fmt.Fprintf(bw, "    outer loop\n")
+		for _, v := range f.Vertices {
+			fmt.Fprintf(bw, "      vertex %s\n", formatV3(v))
+		}
+		fmt.Fprintf(bw, "    endloop\n")
+		fmt.Fprintf(bw, "  endfacet\n")
+	}
+	fmt.Fprintf(bw, "endsolid %s\n", s.Name)
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("error encoding ascii STL: %v", err)
+	}
+	return nil
+}
+
+func formatV3(v vector.V3) string {
+	return formatFloat(v.X) + " " + formatFloat(v.Y) + " " + formatFloat(v.Z)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'e', -1, 32)
+}
+
+// binaryHeader is written at the start of binary STLs. It must not begin
+// with "solid", or readers may mistake the file for ASCII.
+const binaryHeader = "binary STL written by sigint.ca/slice"
+
+// WriteBinary writes s to w in binary STL format, including each facet's
+// attribute bytes. The solid's header is written if it has one, so that
+// any colors it defines are preserved.
+func WriteBinary(w io.Writer, s *Solid) error {
+	if uint64(len(s.Facets)) > math.MaxUint32 {
+		return fmt.Errorf("error encoding STL: too many facets: %d", len(s.Facets))
+	}
+
+	bw := bufio.NewWriter(w)
+	var header [80]byte
+	if s.Header != nil {
+		copy(header[:], s.Header)
+	} else {
+		copy(header[:], binaryHeader)
+	}
+	bw.Write(header[:])
+
+	buf := make([]byte, 50)
+	binary.LittleEndian.PutUint32(buf, uint32(len(s.Facets)))
+	bw.Write(buf[:4])
+
+	for _, f := range s.Facets {
+		putV3(buf[0:12], f.Normal)
+		for i, v := range f.Vertices {
+			putV3(buf[12+12*i:24+12*i], v)
+		}
+		binary.LittleEndian.PutUint16(buf[48:50], f.Attr)
+		bw.Write(buf)
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("error encoding STL: %v", err)
+	}
+	return nil
+}
+
+func putV3(b []byte, v vector.V3) {
+	binary.LittleEndian.PutUint32(b[0:4], math.Float32bits(float32(v.X)))
+	binary.LittleEndian.PutUint32(b[4:8], math.Float32bits(float32(v.Y)))
+	binary.LittleEndian.PutUint32(b[8:12], math.Float32bits(float32(v.Z)))
+}
@@ -0,0 +1,57 @@
+package stl
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestWriteRoundTrip(t *testing.T) {
+	paths := []string{
+		"../testdata/cube20_ascii.stl",
+		"../testdata/cube40_binary.stl",
+		"../testdata/pikachu.stl",
+	}
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := Parse(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range want.Facets {
+			want.Facets[i].Attr = uint16(i)
+		}
+
+		for _, format := range []Format{ASCII, Binary} {
+			var buf bytes.Buffer
+			if err := Encode(&buf, want, format); err != nil {
+				t.Fatalf("%s: encode %v: %v", path, format, err)
+			}
+			got, err := Parse(&buf)
+			if err != nil {
+				t.Fatalf("%s: parse %v: %v", path, format, err)
+			}
+
+			if len(got.Facets) != len(want.Facets) {
+				t.Fatalf("%s (%v): got %d facets, want %d", path, format, len(got.Facets), len(want.Facets))
+			}
+			for i := range want.Facets {
+				g, w := got.Facets[i], want.Facets[i]
+				if g.Vertices != w.Vertices {
+					t.Fatalf("%s (%v): facet %d: got %v, want %v", path, format, i, g, w)
+				}
+				if format == Binary && g.Attr != w.Attr {
+					t.Fatalf("%s (%v): facet %d: got attr %d, want %d", path, format, i, g.Attr, w.Attr)
+				}
+			}
+			if fmt.Sprint(got.Bounds()) != fmt.Sprint(want.Bounds()) {
+				t.Errorf("%s (%v): bounds changed", path, format)
+			}
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package threemf
+
+import "encoding/json"
+
+// Settings are the slicing settings stored in a package. They mirror the
+// fields of slice.Config which describe how a model is to be printed,
+// without depending on the slicer; slice.Config.Settings and
+// slice.ConfigFromSettings convert between the two.
+type Settings struct {
+	LayerHeight      float64       `json:"layerHeight"`
+	LineWidth        float64       `json:"lineWidth"`
+	Speed            float64       `json:"speed"`
+	FirstLayerHeight float64       `json:"firstLayerHeight,omitempty"`
+	FirstLayerWidth  float64       `json:"firstLayerWidth,omitempty"`
+	FirstLayerSpeed  float64       `json:"firstLayerSpeed,omitempty"`
+	Adaptive         bool          `json:"adaptive,omitempty"`
+	MinLayerHeight   float64       `json:"minLayerHeight,omitempty"`
+	MaxLayerHeight   float64       `json:"maxLayerHeight,omitempty"`
+	CuspHeight       float64       `json:"cuspHeight,omitempty"`
+	HeightRanges     []HeightRange `json:"heightRanges,omitempty"`
+	Infill           *Infill       `json:"infill,omitempty"`
+}
+
+// A HeightRange overrides the layer height between Min and Max.
+type HeightRange struct {
+	Min, Max float64
+	Height   float64
+}
+
+// An Infill names an infill pattern and its parameters.
+type Infill struct {
+	Type    string  `json:"type"` // e.g. "concentric"
+	Spacing float64 `json:"spacing,omitempty"`
+}
+
+func marshalSettings(s *Settings) ([]byte, error) {
+	return json.MarshalIndent(s, "", "\t")
+}
+
+func unmarshalSettings(b []byte) (*Settings, error) {
+	s := new(Settings)
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
@@ -0,0 +1,154 @@
+// Package threemf reads and writes 3D Manufacturing Format (3MF) packages.
+//
+// Only the core specification is supported: meshes, components and build
+// items. Materials, colors and the other extensions are ignored.
+package threemf
+
+import (
+	"encoding/xml"
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+
+	"sigint.ca/slice/stl"
+	"sigint.ca/slice/vector"
+)
+
+const (
+	modelNamespace   = "http://schemas.microsoft.com/3dmanufacturing/core/2015/02"
+	modelRelType     = "http://schemas.microsoft.com/3dmanufacturing/2013/01/3dmodel"
+	thumbnailRelType = "http://schemas.openxmlformats.org/package/2006/relationships/metadata/thumbnail"
+	configRelType    = "http://sigint.ca/slice/3mf/config"
+	relsNamespace    = "http://schemas.openxmlformats.org/package/2006/relationships"
+	typesNamespace   = "http://schemas.openxmlformats.org/package/2006/content-types"
+	defaultModelPath = "3D/3dmodel.model"
+	thumbnailPath    = "Metadata/thumbnail.png"
+	configPath       = "Metadata/slice_config.json"
+	relsPath         = "_rels/.rels"
+	contentTypesPath = "[Content_Types].xml"
+)
+
+// A Package holds the contents of a 3MF file: the placed solids
+// of a single build plate, along with optional slicing settings
+// and a thumbnail of the plate.
+type Package struct {
+	Solids    []*stl.Solid
+	Settings  *Settings   // nil if the package has no settings
+	Thumbnail image.Image // nil if the package has no thumbnail
+}
+
+// unitScale converts model units into millimeters.
+var unitScale = map[string]float64{
+	"":           1, // millimeter is the default
+	"micron":     0.001,
+	"millimeter": 1,
+	"centimeter": 10,
+	"inch":       25.4,
+	"foot":       304.8,
+	"meter":      1000,
+}
+
+// XML structure of the model part.
+
+type xmlModel struct {
+	XMLName   xml.Name      `xml:"model"`
+	Xmlns     string        `xml:"xmlns,attr"`
+	Unit      string        `xml:"unit,attr,omitempty"`
+	Metadata  []xmlMetadata `xml:"metadata"`
+	Resources xmlResources  `xml:"resources"`
+	Build     xmlBuild      `xml:"build"`
+}
+
+type xmlTypes struct {
+	XMLName  xml.Name     `xml:"Types"`
+	Xmlns    string       `xml:"xmlns,attr"`
+	Defaults []xmlDefault `xml:"Default"`
+}
+
+type xmlDefault struct {
+	Extension   string `xml:"Extension,attr"`
+	ContentType string `xml:"ContentType,attr"`
+}
+
+type xmlRelationships struct {
+	XMLName       xml.Name          `xml:"Relationships"`
+	Xmlns         string            `xml:"xmlns,attr"`
+	Relationships []xmlRelationship `xml:"Relationship"`
+}
+
+type xmlRelationship struct {
+	Target string `xml:"Target,attr"`
+	ID     string `xml:"Id,attr"`
+	Type   string `xml:"Type,attr"`
+}
+
+type xmlMetadata struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:",chardata"`
+}
+
+type xmlResources struct {
+	Objects []xmlObject `xml:"object"`
+}
+
+type xmlObject struct {
+	ID         int            `xml:"id,attr"`
+	Name       string         `xml:"name,attr,omitempty"`
+	Type       string         `xml:"type,attr,omitempty"`
+	Mesh       *xmlMesh       `xml:"mesh"`
+	Components []xmlComponent `xml:"components>component"`
+}
+
+type xmlMesh struct {
+	Vertices  []xmlVertex   `xml:"vertices>vertex"`
+	Triangles []xmlTriangle `xml:"triangles>triangle"`
+}
+
+type xmlVertex struct {
+	X float64 `xml:"x,attr"`
+	Y float64 `xml:"y,attr"`
+	Z float64 `xml:"z,attr"`
+}
+
+type xmlTriangle struct {
+	V1 int `xml:"v1,attr"`
+	V2 int `xml:"v2,attr"`
+	V3 int `xml:"v3,attr"`
+}
+
+type xmlComponent struct {
+	ObjectID  int    `xml:"objectid,attr"`
+	Transform string `xml:"transform,attr,omitempty"`
+}
+
+type xmlBuild struct {
+	Items []xmlItem `xml:"item"`
+}
+
+type xmlItem struct {
+	ObjectID  int    `xml:"objectid,attr"`
+	Transform string `xml:"transform,attr,omitempty"`
+}
+
+// parseMatrix parses a 3MF transform: the first three columns of a 4x4
+// matrix which transforms row vectors, given row by row.
+func parseMatrix(s string) (vector.M4, error) {
+	m := vector.Identity()
+	if s == "" {
+		return m, nil
+	}
+	fields := strings.Fields(s)
+	if len(fields) != 12 {
+		return m, fmt.Errorf("bad transform %q: need 12 values", s)
+	}
+	for i, f := range fields {
+		v, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return m, fmt.Errorf("bad transform %q: %v", s, err)
+		}
+		// transpose, for column vectors
+		m[i%3][i/3] = v
+	}
+	return m, nil
+}
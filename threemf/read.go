@@ -0,0 +1,213 @@
+package threemf
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"image"
+	_ "image/png" // for thumbnails
+	"io"
+	"path"
+	"strings"
+
+	"sigint.ca/slice/stl"
+	"sigint.ca/slice/vector"
+)
+
+// maxDepth limits the nesting of components, to guard against cycles.
+const maxDepth = 32
+
+// Parse reads a 3MF package of the given size from r, and returns a solid
+// for each item of its build, with the item's transform and those of its
+// components applied and its coordinates converted into millimeters.
+func Parse(r io.ReaderAt, size int64) ([]*stl.Solid, error) {
+	p, err := Decode(r, size)
+	if err != nil {
+		return nil, err
+	}
+	return p.Solids, nil
+}
+
+// Decode reads a 3MF package of the given size from r. Its solids are
+// placed as described for Parse; settings and a thumbnail are returned
+// if the package has them.
+func Decode(r io.ReaderAt, size int64) (*Package, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding 3MF: %v", err)
+	}
+	p, err := decode(zr)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding 3MF: %v", err)
+	}
+	return p, nil
+}
+
+func decode(zr *zip.Reader) (*Package, error) {
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[strings.TrimPrefix(f.Name, "/")] = f
+	}
+
+	// find the parts through the package relationships
+	parts := make(map[string]string) // part names by relationship type
+	if f, ok := files[relsPath]; ok {
+		var rels xmlRelationships
+		if err := decodeXML(f, &rels); err != nil {
+			return nil, err
+		}
+		for _, rel := range rels.Relationships {
+			if _, ok := parts[rel.Type]; !ok {
+				parts[rel.Type] = strings.TrimPrefix(path.Clean(rel.Target), "/")
+			}
+		}
+	}
+	if _, ok := parts[modelRelType]; !ok {
+		parts[modelRelType] = defaultModelPath
+	}
+
+	f, ok := files[parts[modelRelType]]
+	if !ok {
+		return nil, fmt.Errorf("no model part %q", parts[modelRelType])
+	}
+	var m xmlModel
+	if err := decodeXML(f, &m); err != nil {
+		return nil, err
+	}
+	solids, err := m.build()
+	if err != nil {
+		return nil, err
+	}
+	p := &Package{Solids: solids}
+
+	if f, ok := files[parts[configRelType]]; ok {
+		b, err := readFile(f)
+		if err != nil {
+			return nil, err
+		}
+		if p.Settings, err = unmarshalSettings(b); err != nil {
+			return nil, fmt.Errorf("%s: %v", f.Name, err)
+		}
+	}
+	if f, ok := files[parts[thumbnailRelType]]; ok {
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", f.Name, err)
+		}
+		p.Thumbnail, _, err = image.Decode(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", f.Name, err)
+		}
+	}
+	return p, nil
+}
+
+func readFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", f.Name, err)
+	}
+	defer rc.Close()
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", f.Name, err)
+	}
+	return b, nil
+}
+
+func decodeXML(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("%s: %v", f.Name, err)
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("%s: %v", f.Name, err)
+	}
+	return nil
+}
+
+// build returns the placed solids of the model's build items.
+func (m *xmlModel) build() ([]*stl.Solid, error) {
+	scale, ok := unitScale[m.Unit]
+	if !ok {
+		return nil, fmt.Errorf("unknown unit %q", m.Unit)
+	}
+	units := vector.Scaling(vector.V3{X: scale, Y: scale, Z: scale})
+
+	objects := make(map[int]*xmlObject)
+	for i := range m.Resources.Objects {
+		o := &m.Resources.Objects[i]
+		objects[o.ID] = o
+	}
+
+	solids := make([]*stl.Solid, 0, len(m.Build.Items))
+	for _, item := range m.Build.Items {
+		o, ok := objects[item.ObjectID]
+		if !ok {
+			return nil, fmt.Errorf("build item refers to unknown object %d", item.ObjectID)
+		}
+		t, err := parseMatrix(item.Transform)
+		if err != nil {
+			return nil, err
+		}
+		facets, err := appendFacets(make([]stl.Facet, 0), o, units.Mul(t), objects, 0)
+		if err != nil {
+			return nil, err
+		}
+		s := stl.NewSolid(facets)
+		s.Name = o.Name
+		if s.Name == "" {
+			s.Name = fmt.Sprintf("object %d", o.ID)
+		}
+		s.ReconcileNormals(stl.TrustWinding)
+		solids = append(solids, s)
+	}
+	return solids, nil
+}
+
+// appendFacets appends the facets of o, transformed by t, to facets.
+func appendFacets(facets []stl.Facet, o *xmlObject, t vector.M4, objects map[int]*xmlObject, depth int) ([]stl.Facet, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("object %d: components nested too deeply", o.ID)
+	}
+
+	if o.Mesh != nil {
+		vertices := make([]vector.V3, len(o.Mesh.Vertices))
+		for i, v := range o.Mesh.Vertices {
+			vertices[i] = t.Apply(vector.V3{X: v.X, Y: v.Y, Z: v.Z})
+		}
+		mirrored := t.Det() < 0
+		for i, tri := range o.Mesh.Triangles {
+			idx := [3]int{tri.V1, tri.V2, tri.V3}
+			if mirrored {
+				idx[1], idx[2] = idx[2], idx[1]
+			}
+			var f stl.Facet
+			for j, vi := range idx {
+				if vi < 0 || vi >= len(vertices) {
+					return nil, fmt.Errorf("object %d: triangle %d: bad vertex index %d", o.ID, i, vi)
+				}
+				f.Vertices[j] = vertices[vi]
+			}
+			facets = append(facets, f)
+		}
+	}
+
+	for _, c := range o.Components {
+		co, ok := objects[c.ObjectID]
+		if !ok {
+			return nil, fmt.Errorf("object %d: component refers to unknown object %d", o.ID, c.ObjectID)
+		}
+		ct, err := parseMatrix(c.Transform)
+		if err != nil {
+			return nil, err
+		}
+		facets, err = appendFacets(facets, co, t.Mul(ct), objects, depth+1)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return facets, nil
+}
@@ -0,0 +1,154 @@
+package threemf
+
+import (
+	"archive/zip"
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"sigint.ca/slice/vector"
+)
+
+// makePackage returns a 3MF package holding the given model, with the
+// model part at name.
+func makePackage(t *testing.T, name, model string) *bytes.Reader {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	parts := []struct{ name, body string }{
+		{contentTypesPath, `<?xml version="1.0" encoding="UTF-8"?>
+<Types xmlns="` + typesNamespace + `">
+ <Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+ <Default Extension="model" ContentType="application/vnd.ms-package.3dmanufacturing-3dmodel+xml"/>
+</Types>`},
+		{relsPath, `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="` + relsNamespace + `">
+ <Relationship Target="/` + name + `" Id="rel0" Type="` + modelRelType + `"/>
+</Relationships>`},
+		{name, model},
+	}
+	for _, p := range parts {
+		w, err := zw.Create(p.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(p.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+// a unit cube with outward (counter-clockwise) winding
+const cubeMesh = `<mesh>
+ <vertices>
+  <vertex x="0" y="0" z="0"/><vertex x="1" y="0" z="0"/>
+  <vertex x="1" y="1" z="0"/><vertex x="0" y="1" z="0"/>
+  <vertex x="0" y="0" z="1"/><vertex x="1" y="0" z="1"/>
+  <vertex x="1" y="1" z="1"/><vertex x="0" y="1" z="1"/>
+ </vertices>
+ <triangles>
+  <triangle v1="0" v2="2" v3="1"/><triangle v1="0" v2="3" v3="2"/>
+  <triangle v1="4" v2="5" v3="6"/><triangle v1="4" v2="6" v3="7"/>
+  <triangle v1="0" v2="1" v3="5"/><triangle v1="0" v2="5" v3="4"/>
+  <triangle v1="1" v2="2" v3="6"/><triangle v1="1" v2="6" v3="5"/>
+  <triangle v1="2" v2="3" v3="7"/><triangle v1="2" v2="7" v3="6"/>
+  <triangle v1="3" v2="0" v3="4"/><triangle v1="3" v2="4" v3="7"/>
+ </triangles>
+</mesh>`
+
+const model = `<?xml version="1.0" encoding="UTF-8"?>
+<model unit="centimeter" xml:lang="en-US" xmlns="` + modelNamespace + `">
+ <resources>
+  <object id="1" name="cube" type="model">` + cubeMesh + `</object>
+  <object id="2" name="pair" type="model">
+   <components>
+    <component objectid="1"/>
+    <component objectid="1" transform="1 0 0 0 1 0 0 0 1 2 0 0"/>
+   </components>
+  </object>
+ </resources>
+ <build>
+  <item objectid="1" transform="2 0 0 0 2 0 0 0 2 5 5 0"/>
+  <item objectid="2" transform="-1 0 0 0 1 0 0 0 1 0 0 1"/>
+ </build>
+</model>`
+
+func TestParse(t *testing.T) {
+	r := makePackage(t, "3D/model.model", model)
+	solids, err := Parse(r, r.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(solids) != 2 {
+		t.Fatalf("got %d solids, want 2", len(solids))
+	}
+
+	tests := []struct {
+		name     string
+		facets   int
+		min, max vector.V3
+		volume   float64
+	}{
+		// scaled up 2x, then moved; the unit is cm
+		{"cube", 12, vector.V3{X: 50, Y: 50, Z: 0}, vector.V3{X: 70, Y: 70, Z: 20}, 8000},
+		// two cubes side by side, mirrored in x and raised
+		{"pair", 24, vector.V3{X: -30, Y: 0, Z: 10}, vector.V3{X: 0, Y: 10, Z: 20}, 2000},
+	}
+	for i, test := range tests {
+		s := solids[i]
+		if s.Name != test.name {
+			t.Errorf("solid %d: got name %q, want %q", i, s.Name, test.name)
+		}
+		if len(s.Facets) != test.facets {
+			t.Errorf("%s: got %d facets, want %d", test.name, len(s.Facets), test.facets)
+		}
+		min, max := s.Bounds()
+		if min != test.min || max != test.max {
+			t.Errorf("%s: got bounds %v-%v, want %v-%v", test.name, min, max, test.min, test.max)
+		}
+		if v := s.Volume(); math.Abs(v-test.volume) > 1e-6 {
+			t.Errorf("%s: got volume %v, want %v", test.name, v, test.volume)
+		}
+	}
+
+	// the normals of a convex solid point away from its middle
+	min, max := solids[0].Bounds()
+	mid := min.Add(max).Mul(0.5)
+	for i, f := range solids[0].Facets {
+		c := f.Vertices[0].Add(f.Vertices[1]).Add(f.Vertices[2]).Mul(1.0 / 3)
+		if f.Normal.Dot(c.Sub(mid)) <= 0 {
+			t.Errorf("cube: facet %d: normal %v points inwards", i, f.Normal)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name, body, err string
+	}{
+		{"unknown object", `<build><item objectid="7"/></build>`, "unknown object 7"},
+		{"bad index", `<resources><object id="1"><mesh><vertices><vertex x="0" y="0" z="0"/></vertices>
+			<triangles><triangle v1="0" v2="1" v3="2"/></triangles></mesh></object></resources>
+			<build><item objectid="1"/></build>`, "bad vertex index 1"},
+		{"bad transform", `<resources><object id="1">` + cubeMesh + `</object></resources>
+			<build><item objectid="1" transform="1 0 0"/></build>`, "need 12 values"},
+		{"cycle", `<resources><object id="1"><components><component objectid="1"/></components></object></resources>
+			<build><item objectid="1"/></build>`, "nested too deeply"},
+	}
+	for _, test := range tests {
+		body := `<model xmlns="` + modelNamespace + `">` + test.body + `</model>`
+		r := makePackage(t, defaultModelPath, body)
+		_, err := Parse(r, r.Size())
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: got error %v, want %q", test.name, err, test.err)
+		}
+	}
+
+	if _, err := Parse(strings.NewReader("solid cube"), 10); err == nil {
+		t.Error("no error for non-zip input")
+	}
+}
@@ -0,0 +1,145 @@
+package threemf
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"image/png"
+	"io"
+
+	"sigint.ca/slice/stl"
+	"sigint.ca/slice/vector"
+)
+
+// Encode writes p to w as a 3MF package. Each solid becomes an object
+// with a build item of its own, in place, so that other tools show the
+// plate as it was sliced. Vertices shared between facets are merged.
+func Encode(w io.Writer, p *Package) error {
+	if err := encode(w, p); err != nil {
+		return fmt.Errorf("error encoding 3MF: %v", err)
+	}
+	return nil
+}
+
+func encode(w io.Writer, p *Package) error {
+	types := xmlTypes{
+		Xmlns: typesNamespace,
+		Defaults: []xmlDefault{
+			{"rels", "application/vnd.openxmlformats-package.relationships+xml"},
+			{"model", "application/vnd.ms-package.3dmanufacturing-3dmodel+xml"},
+		},
+	}
+	rels := xmlRelationships{
+		Xmlns: relsNamespace,
+		Relationships: []xmlRelationship{
+			{Target: "/" + defaultModelPath, ID: "rel0", Type: modelRelType},
+		},
+	}
+
+	var config []byte
+	if p.Settings != nil {
+		var err error
+		if config, err = marshalSettings(p.Settings); err != nil {
+			return err
+		}
+		types.Defaults = append(types.Defaults, xmlDefault{"json", "application/json"})
+		rels.Relationships = append(rels.Relationships,
+			xmlRelationship{Target: "/" + configPath, ID: "rel1", Type: configRelType})
+	}
+	if p.Thumbnail != nil {
+		types.Defaults = append(types.Defaults, xmlDefault{"png", "image/png"})
+		rels.Relationships = append(rels.Relationships,
+			xmlRelationship{Target: "/" + thumbnailPath, ID: "rel2", Type: thumbnailRelType})
+	}
+
+	zw := zip.NewWriter(w)
+	if err := writeXML(zw, contentTypesPath, types); err != nil {
+		return err
+	}
+	if err := writeXML(zw, relsPath, rels); err != nil {
+		return err
+	}
+	if err := writeXML(zw, defaultModelPath, newModel(p.Solids)); err != nil {
+		return err
+	}
+	if config != nil {
+		f, err := zw.Create(configPath)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(config); err != nil {
+			return err
+		}
+	}
+	if p.Thumbnail != nil {
+		f, err := zw.Create(thumbnailPath)
+		if err != nil {
+			return err
+		}
+		if err := png.Encode(f, p.Thumbnail); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func writeXML(zw *zip.Writer, name string, v interface{}) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(f, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(f)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	return enc.Close()
+}
+
+// newModel returns a model with an object and build item for each solid.
+func newModel(solids []*stl.Solid) *xmlModel {
+	m := &xmlModel{
+		Xmlns: modelNamespace,
+		Unit:  "millimeter",
+		Metadata: []xmlMetadata{
+			{Name: "Application", Value: "sigint.ca/slice"},
+		},
+	}
+	for i, s := range solids {
+		id := i + 1
+		m.Resources.Objects = append(m.Resources.Objects, xmlObject{
+			ID:   id,
+			Name: s.Name,
+			Type: "model",
+			Mesh: newMesh(s),
+		})
+		m.Build.Items = append(m.Build.Items, xmlItem{ObjectID: id})
+	}
+	return m
+}
+
+// newMesh returns an indexed mesh of the facets of s, merging
+// identical vertices.
+func newMesh(s *stl.Solid) *xmlMesh {
+	m := &xmlMesh{
+		Vertices:  make([]xmlVertex, 0, len(s.Facets)/2),
+		Triangles: make([]xmlTriangle, 0, len(s.Facets)),
+	}
+	index := make(map[vector.V3]int)
+	for _, f := range s.Facets {
+		var idx [3]int
+		for j, v := range f.Vertices {
+			i, ok := index[v]
+			if !ok {
+				i = len(m.Vertices)
+				index[v] = i
+				m.Vertices = append(m.Vertices, xmlVertex{X: v.X, Y: v.Y, Z: v.Z})
+			}
+			idx[j] = i
+		}
+		m.Triangles = append(m.Triangles, xmlTriangle{V1: idx[0], V2: idx[1], V3: idx[2]})
+	}
+	return m
+}
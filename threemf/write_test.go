@@ -0,0 +1,84 @@
+package threemf
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"os"
+	"reflect"
+	"testing"
+
+	"sigint.ca/slice/stl"
+)
+
+func TestEncode(t *testing.T) {
+	f, err := os.Open("../testdata/cube20_ascii.stl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	cube, err := stl.Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	thumb := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	thumb.Set(1, 2, color.NRGBA{R: 255, A: 255})
+	settings := &Settings{
+		LayerHeight:      0.2,
+		LineWidth:        0.4,
+		Speed:            50,
+		FirstLayerHeight: 0.3,
+		HeightRanges:     []HeightRange{{Min: 5, Max: 10, Height: 0.1}},
+		Infill:           &Infill{Type: "concentric", Spacing: 0.5},
+	}
+	in := &Package{
+		Solids:    []*stl.Solid{cube},
+		Settings:  settings,
+		Thumbnail: thumb,
+	}
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, in); err != nil {
+		t.Fatal(err)
+	}
+	r := bytes.NewReader(buf.Bytes())
+	out, err := Decode(r, r.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(out.Solids) != 1 {
+		t.Fatalf("got %d solids, want 1", len(out.Solids))
+	}
+	s := out.Solids[0]
+	if s.Name != cube.Name {
+		t.Errorf("got name %q, want %q", s.Name, cube.Name)
+	}
+	if !reflect.DeepEqual(s.Facets, cube.Facets) {
+		t.Errorf("facets changed:\ngot  %v\nwant %v", s.Facets, cube.Facets)
+	}
+	if !reflect.DeepEqual(out.Settings, settings) {
+		t.Errorf("got settings %+v, want %+v", out.Settings, settings)
+	}
+	if out.Thumbnail == nil || out.Thumbnail.Bounds() != thumb.Bounds() {
+		t.Fatalf("got thumbnail %v, want %v", out.Thumbnail, thumb)
+	}
+	if c := color.NRGBAModel.Convert(out.Thumbnail.At(1, 2)); c != thumb.At(1, 2) {
+		t.Errorf("got thumbnail pixel %v, want %v", c, thumb.At(1, 2))
+	}
+
+	// settings and thumbnails are optional
+	buf.Reset()
+	if err := Encode(&buf, &Package{Solids: []*stl.Solid{cube}}); err != nil {
+		t.Fatal(err)
+	}
+	r = bytes.NewReader(buf.Bytes())
+	out, err = Decode(r, r.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Settings != nil || out.Thumbnail != nil {
+		t.Errorf("got settings %v and thumbnail %v, want neither", out.Settings, out.Thumbnail)
+	}
+}
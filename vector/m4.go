@@ -0,0 +1,107 @@
+package vector
+
+import (
+	"fmt"
+	"math"
+)
+
+// An M4 is a 4x4 matrix describing an affine transform of points,
+// which are treated as column vectors: p' = M·p. It is indexed by
+// row, then column; the translation is in the last column.
+type M4 [4][4]float64
+
+// Identity returns the identity transform.
+func Identity() M4 {
+	return M4{
+		{1, 0, 0, 0},
+		{0, 1, 0, 0},
+		{0, 0, 1, 0},
+		{0, 0, 0, 1},
+	}
+}
+
+// Translation returns a transform which moves points by d.
+func Translation(d V3) M4 {
+	m := Identity()
+	m[0][3], m[1][3], m[2][3] = d.X, d.Y, d.Z
+	return m
+}
+
+// Scaling returns a transform which scales points away from the
+// origin by the factors in s, along each axis.
+func Scaling(s V3) M4 {
+	m := Identity()
+	m[0][0], m[1][1], m[2][2] = s.X, s.Y, s.Z
+	return m
+}
+
+// Rotation returns a transform which rotates points by angle radians
+// about axis, which passes through the origin. The rotation is
+// counter-clockwise when looking back along the axis.
+func Rotation(axis V3, angle float64) M4 {
+	a := axis.Normalize()
+	s, c := math.Sincos(angle)
+	t := 1 - c
+	return M4{
+		{t*a.X*a.X + c, t*a.X*a.Y - s*a.Z, t*a.X*a.Z + s*a.Y, 0},
+		{t*a.X*a.Y + s*a.Z, t*a.Y*a.Y + c, t*a.Y*a.Z - s*a.X, 0},
+		{t*a.X*a.Z - s*a.Y, t*a.Y*a.Z + s*a.X, t*a.Z*a.Z + c, 0},
+		{0, 0, 0, 1},
+	}
+}
+
+// Reflection returns a transform which mirrors points in the plane
+// through the origin with the given normal.
+func Reflection(normal V3) M4 {
+	n := normal.Normalize()
+	return M4{
+		{1 - 2*n.X*n.X, -2 * n.X * n.Y, -2 * n.X * n.Z, 0},
+		{-2 * n.Y * n.X, 1 - 2*n.Y*n.Y, -2 * n.Y * n.Z, 0},
+		{-2 * n.Z * n.X, -2 * n.Z * n.Y, 1 - 2*n.Z*n.Z, 0},
+		{0, 0, 0, 1},
+	}
+}
+
+// Mul returns the product m·n, which applies n, followed by m.
+func (m M4) Mul(n M4) M4 {
+	var r M4
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			for k := 0; k < 4; k++ {
+				r[i][j] += m[i][k] * n[k][j]
+			}
+		}
+	}
+	return r
+}
+
+// Apply returns the point v transformed by m.
+func (m M4) Apply(v V3) V3 {
+	return V3{
+		X: m[0][0]*v.X + m[0][1]*v.Y + m[0][2]*v.Z + m[0][3],
+		Y: m[1][0]*v.X + m[1][1]*v.Y + m[1][2]*v.Z + m[1][3],
+		Z: m[2][0]*v.X + m[2][1]*v.Y + m[2][2]*v.Z + m[2][3],
+	}
+}
+
+// ApplyDir returns the direction v transformed by m,
+// ignoring any translation.
+func (m M4) ApplyDir(v V3) V3 {
+	return V3{
+		X: m[0][0]*v.X + m[0][1]*v.Y + m[0][2]*v.Z,
+		Y: m[1][0]*v.X + m[1][1]*v.Y + m[1][2]*v.Z,
+		Z: m[2][0]*v.X + m[2][1]*v.Y + m[2][2]*v.Z,
+	}
+}
+
+// Det returns the determinant of the linear (upper left 3x3) part of m.
+// It is negative if m mirrors, reversing the handedness of shapes.
+func (m M4) Det() float64 {
+	return m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+		m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+		m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+}
+
+func (m M4) String() string {
+	return fmt.Sprintf("[%v %v %v %v]", m[0], m[1], m[2], m[3])
+}
@@ -21,6 +21,20 @@ func (v V3) Mul(d float64) V3 {
 	return V3{X: v.X * d, Y: v.Y * d, Z: v.Z * d}
 }
 
+// Dot returns the dot product of v1 and v2.
+func (v1 V3) Dot(v2 V3) float64 {
+	return v1.X*v2.X + v1.Y*v2.Y + v1.Z*v2.Z
+}
+
+// Cross returns the cross product of v1 and v2.
+func (v1 V3) Cross(v2 V3) V3 {
+	return V3{
+		X: (v1.Y * v2.Z) - (v1.Z * v2.Y),
+		Y: (v1.Z * v2.X) - (v1.X * v2.Z),
+		Z: (v1.X * v2.Y) - (v1.Y * v2.X),
+	}
+}
+
 func (v V3) Length() float64 {
 	return math.Sqrt((v.X * v.X) + (v.Y * v.Y) + (v.Z * v.Z))
 }